@@ -0,0 +1,105 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	"ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+	"ovn4nfv-k8s-plugin/pkg/generated/clientset/versioned"
+	nfnscheme "ovn4nfv-k8s-plugin/pkg/generated/clientset/versioned/scheme"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// recorder emits the events of the agent, nfnClient and kubeClient read the
+// provider networks and the pods the events are about
+var (
+	recorder   record.EventRecorder
+	nfnClient  versioned.Interface
+	kubeClient kubernetes.Interface
+)
+
+// newEventRecorder returns a recorder of the events of the agent on the
+// node, for both core and nfn objects
+func newEventRecorder(clientset kubernetes.Interface, nodeName string) (record.EventRecorder, error) {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		return nil, err
+	}
+	if err := nfnscheme.AddToScheme(s); err != nil {
+		return nil, err
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	return broadcaster.NewRecorder(s, corev1.EventSource{Component: "nfn-agent", Host: nodeName}), nil
+}
+
+// providerNetworkWarning emits a warning event on the provider network of
+// the logical switch nwName
+func providerNetworkWarning(nwName, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil || nfnClient == nil {
+		return
+	}
+	namespace, name := ovn.SplitProviderNetworkName(nwName)
+	pn, err := nfnClient.K8sV1alpha1().ProviderNetworks(namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) && nwName == name {
+		pn, err = legacyProviderNetwork(name)
+	}
+	if err != nil {
+		log.Error(err, "Unable to record event on provider network", "namespace", namespace, "name", name)
+		return
+	}
+	recorder.Eventf(pn, corev1.EventTypeWarning, reason, messageFmt+" on node %s", append(args, os.Getenv("NFN_NODE_NAME"))...)
+}
+
+// legacyProviderNetwork returns the provider network of any namespace named
+// name, the switches created before the namespace prefix are named after
+// the provider network only
+func legacyProviderNetwork(name string) (*v1alpha1.ProviderNetwork, error) {
+	pns, err := nfnClient.K8sV1alpha1().ProviderNetworks("").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pns.Items {
+		if pns.Items[i].Name == name {
+			return &pns.Items[i], nil
+		}
+	}
+	return nil, errors.NewNotFound(v1alpha1.Resource("providernetwork"), name)
+}
+
+// podEvent emits an event on the pod of the node
+func podEvent(namespace, name, eventtype, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil || kubeClient == nil {
+		return
+	}
+	pod, err := kubeClient.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		log.Error(err, "Unable to record event on pod", "namespace", namespace, "name", name)
+		return
+	}
+	recorder.Eventf(pod, eventtype, reason, messageFmt, args...)
+}
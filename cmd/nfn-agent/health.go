@@ -0,0 +1,69 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/metrics"
+)
+
+const defaultHealthPort = "9030"
+
+// connected is set while the agent is subscribed to the operator and in sync
+var connected int32
+
+func setConnected(c bool) {
+	var v int32
+	if c {
+		v = 1
+	}
+	atomic.StoreInt32(&connected, v)
+}
+
+func isConnected() bool {
+	return atomic.LoadInt32(&connected) == 1
+}
+
+// startHealthServer serves /healthz, the agent is alive and serving CNI
+// requests, /readyz, the agent is connected to the operator and in sync, and
+// the Prometheus /metrics. The port can be set with NFN_AGENT_HEALTH_PORT.
+func startHealthServer() {
+	port := os.Getenv("NFN_AGENT_HEALTH_PORT")
+	if port == "" {
+		port = defaultHealthPort
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !isConnected() {
+			http.Error(w, "not connected to nfn-operator", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	go func() {
+		if err := http.ListenAndServe(":"+port, mux); err != nil {
+			log.Error(err, "Health server stopped", "port", port)
+		}
+	}()
+}
@@ -25,18 +25,22 @@ import (
 	cs "ovn4nfv-k8s-plugin/internal/pkg/cniserver"
 	pb "ovn4nfv-k8s-plugin/internal/pkg/nfnNotify/proto"
 	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
 	chaining "ovn4nfv-k8s-plugin/internal/pkg/utils"
+	"ovn4nfv-k8s-plugin/pkg/generated/clientset/versioned"
 	"strings"
 	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	kexec "k8s.io/utils/exec"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 
-	//"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/keepalive"
 
 	"ovn4nfv-k8s-plugin/cmd/ovn4nfvk8s-cni/app"
 
@@ -45,20 +49,36 @@ import (
 )
 
 var log = logf.Log.WithName("nfn-agent")
-var errorChannel chan string
-var inSync bool
-var pnCreateStore []*pb.Notification_ProviderNwCreate
 
-// subscribe Notifications
+// appliedGeneration is the generation of the last notification applied
+var appliedGeneration uint64
+
+// routeFailures are the chain routes not added since the last status report
+var routeFailures []*pb.RouteFailure
+
+// cniServer serves the CNI requests of the node, the changes to running pods
+// are serialized with the requests of their sandbox
+var cniServer *cs.CNIServer
+
+// Backoff between attempts to subscribe to the operator
+const (
+	initialBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+)
+
+// subscribe Notifications. The CNI server keeps serving requests while
+// the agent reconnects to the operator.
 func subscribeNotif(client pb.NfnNotifyClient) error {
 	log.Info("Subscribe Notification from server")
 	ctx := context.Background()
 	var n pb.SubscribeContext
 	n.NodeName = os.Getenv("NFN_NODE_NAME")
+	backoff := initialBackoff
 	for {
 		stream, err := client.Subscribe(ctx, &n, grpc.WaitForReady(true))
 		if err != nil {
 			log.Error(err, "Subscribe", "client", client, "status", status.Code(err))
+			backoff = waitBackoff(backoff)
 			continue
 		}
 		log.Info("Subscribe Notification success")
@@ -66,38 +86,85 @@ func subscribeNotif(client pb.NfnNotifyClient) error {
 		for {
 			in, err := stream.Recv()
 			if err == io.EOF {
-				// read done.
-				shutDownAgent("Stream closed")
-				return err
+				// Operator stopped, subscribe again to the new leader
+				log.Info("Stream closed from server")
+				break
 			}
 			if err != nil {
-				log.Error(err, "Stream closed from server")
-				shutDownAgent("Stream closed from server")
-				return err
+				log.Error(err, "Stream closed from server", "status", status.Code(err))
+				break
 			}
+			backoff = initialBackoff
 			log.Info("Got message", "msg", in)
+			// Generations are contiguous, a gap means a notification was lost
+			resync := in.GetProviderNwSync() == nil && in.GetGeneration() != appliedGeneration+1
+			if resync {
+				log.Info("Notification generation gap", "applied", appliedGeneration, "received", in.GetGeneration())
+			}
 			handleNotif(in)
+			appliedGeneration = in.GetGeneration()
+			reportStatus(client, n.NodeName, resync)
 		}
+		setConnected(false)
+		backoff = waitBackoff(backoff)
 	}
 }
 
+// waitBackoff sleeps for the jittered backoff and returns the next backoff
+func waitBackoff(backoff time.Duration) time.Duration {
+	d := wait.Jitter(backoff, 0.1)
+	log.Info("Reconnecting to operator", "after", d)
+	time.Sleep(d)
+	backoff *= 2
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// reportStatus reports the applied generation to the server and requests a
+// full provider network sync if resync is set
+func reportStatus(client pb.NfnNotifyClient, nodeName string, resync bool) {
+	sr := &pb.StatusReport{
+		NodeName:          nodeName,
+		AppliedGeneration: appliedGeneration,
+		Resync:            resync,
+		RouteFailures:     routeFailures,
+	}
+	ack, err := client.ReportStatus(context.Background(), sr)
+	if err != nil {
+		log.Error(err, "Unable to report status", "applied", appliedGeneration)
+		return
+	}
+	routeFailures = nil
+	log.V(1).Info("Status reported", "applied", appliedGeneration, "generation", ack.GetGeneration())
+}
+
+// vlanLogicalIntf returns the VLAN interface name of the provider network
+func vlanLogicalIntf(pnc *pb.ProviderNetworkCreate) string {
+	ln := pnc.GetVlan().GetLogicalIntf()
+	if ln == "" {
+		ln = ovn.DefaultVlanIntfName(pnc.GetProviderNwName(), pnc.GetVlan().GetVlanId())
+	}
+	return ln
+}
+
 func createVlanProvidernetwork(payload *pb.Notification_ProviderNwCreate) error {
 	var err error
 	vlanID := payload.ProviderNwCreate.GetVlan().GetVlanId()
-	ln := payload.ProviderNwCreate.GetVlan().GetLogicalIntf()
+	ln := vlanLogicalIntf(payload.ProviderNwCreate)
 	pn := payload.ProviderNwCreate.GetVlan().GetProviderIntf()
 	name := payload.ProviderNwCreate.GetProviderNwName()
-	if ln == "" {
-		ln = name + "." + vlanID
-	}
 	err = ovn.CreateVlan(vlanID, pn, ln)
 	if err != nil {
 		log.Error(err, "Unable to create VLAN", "vlan", ln)
+		providerNetworkWarning(name, "CreateVlanFailed", "Failed to create VLAN %s on %s: %v", ln, pn, err)
 		return err
 	}
-	err = ovn.CreatePnBridge("nw_"+name, "br-"+name, ln)
+	err = ovn.CreatePnBridge("nw_"+name, ovn.PnBridgeName(name), ln)
 	if err != nil {
 		log.Error(err, "Unable to create vlan direct bridge", "vlan", pn)
+		providerNetworkWarning(name, "CreateBridgeFailed", "Failed to create bridge for VLAN %s: %v", ln, err)
 		return err
 	}
 	return nil
@@ -107,9 +174,10 @@ func createDirectProvidernetwork(payload *pb.Notification_ProviderNwCreate) erro
 	var err error
 	pn := payload.ProviderNwCreate.GetDirect().GetProviderIntf()
 	name := payload.ProviderNwCreate.GetProviderNwName()
-	err = ovn.CreatePnBridge("nw_"+name, "br-"+name, pn)
+	err = ovn.CreatePnBridge("nw_"+name, ovn.PnBridgeName(name), pn)
 	if err != nil {
 		log.Error(err, "Unable to create direct bridge", "direct", pn)
+		providerNetworkWarning(name, "CreateBridgeFailed", "Failed to create bridge for interface %s: %v", pn, err)
 		return err
 	}
 	return nil
@@ -119,106 +187,45 @@ func deleteVlanProvidernetwork(payload *pb.Notification_ProviderNwRemove) {
 	ln := payload.ProviderNwRemove.GetVlanLogicalIntf()
 	name := payload.ProviderNwRemove.GetProviderNwName()
 	ovn.DeleteVlan(ln)
-	ovn.DeletePnBridge("nw_"+name, "br-"+name)
+	ovn.DeletePnBridge("nw_"+name, ovn.PnBridgeName(name))
 }
 
 func deleteDirectProvidernetwork(payload *pb.Notification_ProviderNwRemove) {
 	ln := payload.ProviderNwRemove.GetVlanLogicalIntf()
 	name := payload.ProviderNwRemove.GetProviderNwName()
 	ovn.DeleteVlan(ln)
-	ovn.DeletePnBridge("nw_"+name, "br-"+name)
+	ovn.DeletePnBridge("nw_"+name, ovn.PnBridgeName(name))
 }
 
-func inSyncVlanProvidernetwork() {
-	var err error
-	// Read config from node
-	vlanList := ovn.GetVlan()
-	pnBridgeList := ovn.GetPnBridge("nfn")
-	diffVlan := make(map[string]bool)
-	diffPnBridge := make(map[string]bool)
-VLAN:
-	for _, pn := range pnCreateStore {
-		if pn.ProviderNwCreate.GetVlan() != nil {
-			continue
+// syncProviderNetworks makes the node match the full set of provider
+// networks sent by the server. VLANs and bridges created by nfn that are
+// not in the set are removed.
+func syncProviderNetworks(pnSync *pb.ProviderNetworkSync) {
+	vlans := make(map[string]bool)
+	bridges := make(map[string]bool)
+	for _, pnc := range pnSync.GetProviderNw() {
+		payload := &pb.Notification_ProviderNwCreate{ProviderNwCreate: pnc}
+		if pnc.GetVlan() != nil {
+			vlans[vlanLogicalIntf(pnc)] = true
+			createVlanProvidernetwork(payload)
 		}
-		id := pn.ProviderNwCreate.GetVlan().GetVlanId()
-		ln := pn.ProviderNwCreate.GetVlan().GetLogicalIntf()
-		pn := pn.ProviderNwCreate.GetVlan().GetProviderIntf()
-		if ln == "" {
-			ln = pn + "." + id
-		}
-		for _, vlan := range vlanList {
-			if vlan == ln {
-				// VLAN already present
-				diffVlan[vlan] = true
-				continue VLAN
-			}
-		}
-		// Vlan not found
-		err = ovn.CreateVlan(id, pn, ln)
-		if err != nil {
-			log.Error(err, "Unable to create VLAN", "vlan", ln)
-			return
+		if pnc.GetDirect() != nil {
+			createDirectProvidernetwork(payload)
 		}
-	}
-PRNETWORK:
-	for _, pn := range pnCreateStore {
-		if pn.ProviderNwCreate.GetVlan() != nil {
-			continue
-		}
-		ln := pn.ProviderNwCreate.GetVlan().GetLogicalIntf()
-		name := pn.ProviderNwCreate.GetProviderNwName()
-		for _, br := range pnBridgeList {
-			pnName := strings.Replace(br, "br-", "", -1)
-			if name == pnName {
-				diffPnBridge[br] = true
-				continue PRNETWORK
-			}
-		}
-		// Provider Network not found
-		ovn.CreatePnBridge("nw_"+name, "br-"+name, ln)
+		bridges[ovn.PnBridgeName(pnc.GetProviderNwName())] = true
 	}
 	// Delete VLAN not in the list
-	for _, vlan := range vlanList {
-		if diffVlan[vlan] == false {
+	for _, vlan := range ovn.GetVlan() {
+		if !vlans[vlan] {
+			log.Info("Delete stale VLAN", "vlan", vlan)
 			ovn.DeleteVlan(vlan)
 		}
 	}
 	// Delete Provider Bridge not in the list
-	for _, br := range pnBridgeList {
-		if diffPnBridge[br] == false {
-			name := strings.Replace(br, "br-", "", -1)
-			ovn.DeletePnBridge("nw_"+name, "br-"+name)
-		}
-	}
-}
-
-func inSyncDirectProvidernetwork() {
-	// Read config from node
-	pnBridgeList := ovn.GetPnBridge("nfn")
-	diffPnBridge := make(map[string]bool)
-DIRECTPRNETWORK:
-	for _, pn := range pnCreateStore {
-		if pn.ProviderNwCreate.GetDirect() != nil {
-			continue
-		}
-		pr := pn.ProviderNwCreate.GetDirect().GetProviderIntf()
-		name := pn.ProviderNwCreate.GetProviderNwName()
-		for _, br := range pnBridgeList {
-			pnName := strings.Replace(br, "br-", "", -1)
-			if name == pnName {
-				diffPnBridge[br] = true
-				continue DIRECTPRNETWORK
-			}
-		}
-		// Provider Network not found
-		ovn.CreatePnBridge("nw_"+name, "br-"+name, pr)
-	}
-	// Delete Provider Bridge not in the list
-	for _, br := range pnBridgeList {
-		if diffPnBridge[br] == false {
-			name := strings.Replace(br, "br-", "", -1)
-			ovn.DeletePnBridge("nw_"+name, "br-"+name)
+	for br, nwName := range ovn.GetPnBridge("nfn") {
+		if !bridges[br] {
+			log.Info("Delete stale provider network bridge", "bridge", br)
+			ovn.DeletePnBridge(nwName, br)
 		}
 	}
 }
@@ -236,16 +243,33 @@ func createNodeOVSInternalPort(payload *pb.Notification_InSync) error {
 	return nil
 }
 
+// addChainRoutes adds the routes of the chain to the pod
+func addChainRoutes(rt *pb.ContainerRouteInsert) error {
+	podNamespace := rt.GetPodNamespace()
+	podName := rt.GetPodName()
+	found, err := cniServer.WithPodNetns(podNamespace, podName, func(pn *cs.PodNetns) error {
+		return chaining.ContainerAddRoute(pn.Netns, pn.DefaultGW, rt.GetRoute())
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("no network namespace recorded for pod %s/%s", podNamespace, podName)
+	}
+	if err != nil {
+		log.Error(err, "Failed to add chain routes", "chain", rt.GetChain(), "namespace", podNamespace, "pod", podName)
+		podEvent(podNamespace, podName, corev1.EventTypeWarning, "AddChainRoutesFailed",
+			"Failed to add the routes of chain %s: %v", rt.GetChain(), err)
+		return err
+	}
+	return nil
+}
+
 func handleNotif(msg *pb.Notification) {
 	switch msg.GetCniType() {
 	case "ovn4nfv":
 		switch payload := msg.Payload.(type) {
+		case *pb.Notification_ProviderNwSync:
+			syncProviderNetworks(payload.ProviderNwSync)
+
 		case *pb.Notification_ProviderNwCreate:
-			if !inSync {
-				// Store Msgs
-				pnCreateStore = append(pnCreateStore, payload)
-				return
-			}
 			if payload.ProviderNwCreate.GetVlan() != nil {
 				err := createVlanProvidernetwork(payload)
 				if err != nil {
@@ -260,11 +284,6 @@ func handleNotif(msg *pb.Notification) {
 				}
 			}
 		case *pb.Notification_ProviderNwRemove:
-			if !inSync {
-				// Unexpected Remove message
-				return
-			}
-
 			if payload.ProviderNwRemove.GetVlanLogicalIntf() != "" {
 				deleteVlanProvidernetwork(payload)
 			}
@@ -274,28 +293,47 @@ func handleNotif(msg *pb.Notification) {
 			}
 
 		case *pb.Notification_ContainterRtInsert:
-			id := payload.ContainterRtInsert.GetContainerId()
-			pid, err := chaining.GetPidForContainer(id)
-			if err != nil {
-				log.Error(err, "Failed to get pid", "containerID", id)
-				return
+			rt := payload.ContainterRtInsert
+			if err := addChainRoutes(rt); err != nil {
+				// The operator sends the routes again
+				routeFailures = append(routeFailures, &pb.RouteFailure{
+					Chain:        rt.GetChain(),
+					PodNamespace: rt.GetPodNamespace(),
+					PodName:      rt.GetPodName(),
+				})
 			}
-			err = chaining.ContainerAddRoute(pid, payload.ContainterRtInsert.GetRoute())
+
+		case *pb.Notification_ContainterRtRemove:
+			podNamespace := payload.ContainterRtRemove.GetPodNamespace()
+			podName := payload.ContainterRtRemove.GetPodName()
+			found, err := cniServer.WithPodNetns(podNamespace, podName, func(pn *cs.PodNetns) error {
+				return chaining.ContainerDeleteRoute(pn.Netns, pn.DefaultGW, payload.ContainterRtRemove.GetRoute(),
+					payload.ContainterRtRemove.GetLeaveChain())
+			})
 			if err != nil {
+				log.Error(err, "Failed to remove chain routes", "namespace", podNamespace, "pod", podName)
 				return
 			}
+			if !found {
+				// Pod is gone, so are its routes
+				log.Info("Pod netns not found, skip route removal", "namespace", podNamespace, "pod", podName)
+			}
+
+		case *pb.Notification_PodIntfAdd:
+			addPodInterfaces(payload.PodIntfAdd)
+
+		case *pb.Notification_PodIntfRemove:
+			removePodInterfaces(payload.PodIntfRemove)
 
 		case *pb.Notification_InSync:
-			inSyncVlanProvidernetwork()
-			inSyncDirectProvidernetwork()
-			pnCreateStore = nil
-			inSync = true
 			if payload.InSync.GetNodeIntfIpAddress() != "" && payload.InSync.GetNodeIntfMacAddress() != "" {
 				err := createNodeOVSInternalPort(payload)
 				if err != nil {
 					return
 				}
 			}
+			// Provider networks are reconciled, the agent is ready
+			setConnected(true)
 
 		}
 	// Add other Types here
@@ -304,35 +342,60 @@ func handleNotif(msg *pb.Notification) {
 	}
 }
 
-func shutdownHandler(errorChannel <-chan string) {
+// addPodInterfaces plugs the interfaces in the running pod and reports the
+// result on the pod
+func addPodInterfaces(pia *pb.PodInterfaceAdd) {
+	podNamespace := pia.GetPodNamespace()
+	podName := pia.GetPodName()
+	for _, intf := range pia.GetInterface() {
+		err := cniServer.HotplugInterface(podNamespace, podName, podnetwork.InterfaceResult{
+			Interface:  intf.GetInterface(),
+			IPAddress:  intf.GetIpAddress(),
+			MacAddress: intf.GetMacAddress(),
+			GatewayIP:  intf.GetGatewayIp(),
+		}, int(intf.GetMtu()))
+		if err != nil {
+			log.Error(err, "Failed to plug pod interface", "namespace", podNamespace, "pod", podName, "interface", intf.GetInterface())
+			podEvent(podNamespace, podName, corev1.EventTypeWarning, "AddInterfaceFailed",
+				"Failed to plug interface %s: %v", intf.GetInterface(), err)
+			continue
+		}
+		podEvent(podNamespace, podName, corev1.EventTypeNormal, "InterfaceAdded",
+			"Interface %s plugged with address %s", intf.GetInterface(), intf.GetIpAddress())
+	}
+}
+
+// removePodInterfaces unplugs the interfaces from the running pod and reports
+// the result on the pod
+func removePodInterfaces(pir *pb.PodInterfaceRemove) {
+	podNamespace := pir.GetPodNamespace()
+	podName := pir.GetPodName()
+	for _, intf := range pir.GetInterface() {
+		if err := cniServer.HotunplugInterface(podNamespace, podName, intf); err != nil {
+			log.Error(err, "Failed to unplug pod interface", "namespace", podNamespace, "pod", podName, "interface", intf)
+			podEvent(podNamespace, podName, corev1.EventTypeWarning, "RemoveInterfaceFailed",
+				"Failed to unplug interface %s: %v", intf, err)
+			continue
+		}
+		podEvent(podNamespace, podName, corev1.EventTypeNormal, "InterfaceRemoved", "Interface %s unplugged", intf)
+	}
+}
+
+func shutdownHandler() {
 	// Register to receive term/int signal.
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGTERM)
 	signal.Notify(signalChan, syscall.SIGINT)
 	signal.Notify(signalChan, syscall.SIGHUP)
 
-	var reason string
-	select {
-	case sig := <-signalChan:
-		if sig == syscall.SIGHUP {
-			log.Info("Received a SIGHUP")
-		}
-		reason = fmt.Sprintf("Received OS signal %v", sig)
-	case reason = <-errorChannel:
-		log.Info("Error", "reason", reason)
+	sig := <-signalChan
+	if sig == syscall.SIGHUP {
+		log.Info("Received a SIGHUP")
 	}
+	reason := fmt.Sprintf("Received OS signal %v", sig)
 	log.Info("nfn-agent is shutting down", "reason", reason)
 }
 
-func shutDownAgent(reason string) {
-	// Send a failure message and give few seconds complete shutdown.
-	log.Info("shutDownAgent recieved")
-	errorChannel <- reason
-	time.Sleep(10 * time.Second)
-	// The graceful shutdown failed, terminate the process.
-	panic("Shutdown failed. Panicking.")
-}
-
 func main() {
 	logf.SetLogger(zap.Logger(true))
 	log.Info("nfn-agent Started")
@@ -345,14 +408,18 @@ func main() {
 		log.Error(err, "Unable to setup OVN Utils")
 		return
 	}
-	conn, err := grpc.Dial(serverAddr, grpc.WithInsecure())
+	// Keepalive detects an operator that went away without closing the stream
+	conn, err := grpc.Dial(serverAddr, grpc.WithInsecure(), grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:                30 * time.Second,
+		Timeout:             10 * time.Second,
+		PermitWithoutStream: true,
+	}))
 	if err != nil {
 		log.Error(err, "fail to dial")
 		return
 	}
 	defer conn.Close()
 	client := pb.NewNfnNotifyClient(conn)
-	errorChannel = make(chan string)
 
 	// creates the in-cluster config
 	config, err := rest.InClusterConfig()
@@ -368,14 +435,42 @@ func main() {
 		return
 	}
 
-	cniserver := cs.NewCNIServer("", clientset)
-	err = cniserver.Start(cs.HandleCNIcommandRequest)
+	kubeClient = clientset
+	nfnClient, err = versioned.NewForConfig(config)
+	if err != nil {
+		log.Error(err, "Unable to create nfn clientset for in-cluster config")
+		return
+	}
+	recorder, err = newEventRecorder(clientset, os.Getenv("NFN_NODE_NAME"))
+	if err != nil {
+		log.Error(err, "Unable to create event recorder")
+		return
+	}
+
+	cniServer = cs.NewCNIServer("", clientset)
+	cniServer.SetEventRecorder(recorder)
+	addTimeout := cs.DefaultAddTimeout
+	if timeout := os.Getenv("NFN_CNI_ADD_TIMEOUT"); timeout != "" {
+		addTimeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			log.Error(err, "Invalid NFN_CNI_ADD_TIMEOUT", "value", timeout)
+			return
+		}
+	}
+	// CNI ADD waits for the pod annotation on the pods of the node
+	err = cniServer.WatchNodePods(os.Getenv("NFN_NODE_NAME"), addTimeout, wait.NeverStop)
+	if err != nil {
+		log.Error(err, "Unable to watch pods of the node")
+		return
+	}
+	err = cniServer.Start(cs.HandleCNIcommandRequest)
 	if err != nil {
 		log.Error(err, "Unable to start cni server")
 		return
 	}
+	startHealthServer()
 	// Run client in background
 	go subscribeNotif(client)
-	shutdownHandler(errorChannel)
+	shutdownHandler()
 
 }
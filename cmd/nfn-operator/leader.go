@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// leaderLabel marks the pod of the leader. The nfn-operator service selects
+// it so that agents and webhook requests always reach the leader.
+const leaderLabel = "nfn-operator-leader"
+
+// setLeaderLabel sets or removes the leader label of the pod
+func setLeaderLabel(kc kubernetes.Interface, namespace, name string, leader bool) error {
+	var value interface{}
+	if leader {
+		value = "true"
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{leaderLabel: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = kc.CoreV1().Pods(namespace).Patch(name, types.MergePatchType, patch)
+	return err
+}
+
+// leaderManager serves the webhooks from the leader only. The replicas run
+// in the host network of the control plane nodes, a standby replica on the
+// node of the leader must not hold its ports.
+type leaderManager struct {
+	manager.Manager
+	webhookServer *webhook.Server
+}
+
+// GetWebhookServer returns the webhook server of the leader
+func (m *leaderManager) GetWebhookServer() *webhook.Server {
+	return m.webhookServer
+}
+
+// serveMetrics serves the Prometheus metrics on addr till the stop channel
+// is closed
+func serveMetrics(addr string, stop <-chan struct{}) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.HTTPErrorOnError,
+	}))
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-stop
+		server.Shutdown(context.Background())
+	}()
+	log.Info("Serving metrics", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -10,15 +10,19 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"github.com/spf13/pflag"
+	"k8s.io/client-go/kubernetes"
+	"ovn4nfv-k8s-plugin/internal/pkg/netattachdef"
 	notif "ovn4nfv-k8s-plugin/internal/pkg/nfnNotify"
 	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
 	"ovn4nfv-k8s-plugin/pkg/apis"
 	"ovn4nfv-k8s-plugin/pkg/controller"
+	nfnwebhook "ovn4nfv-k8s-plugin/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 var log = logf.Log.WithName("nfn-operator")
@@ -33,6 +37,14 @@ func main() {
 	// Add flags registered by imported packages (e.g. glog and
 	// controller-runtime)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+	leaderElect := pflag.Bool("leader-elect", true, "Enable leader election so that only one replica is active")
+	leaderElectionNamespace := pflag.String("leader-election-namespace", "", "Namespace of the leader election configmap (default: namespace of the pod)")
+	webhookPort := pflag.Int("webhook-port", 9443, "Port of the conversion and admission webhooks")
+	metricsAddr := pflag.String("metrics-addr", ":8383", "Address the Prometheus metrics are served on")
+	webhookCertDir := pflag.String("webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "Directory the webhook serving certificate is written to")
+	pflag.BoolVar(&netattachdef.Enabled, "net-attach-defs", false, "Generate a NetworkAttachmentDefinition per network for Multus")
+	pflag.StringSliceVar(&netattachdef.Namespaces, "net-attach-def-namespaces", netattachdef.Namespaces, "Namespaces the NetworkAttachmentDefinitions of the Networks are generated in")
+	pflag.IntVar(&netattachdef.MTU, "net-attach-def-mtu", netattachdef.MTU, "MTU of the pod interfaces in the generated NetworkAttachmentDefinitions, the MTU of the overlay networks by default")
 
 	pflag.Parse()
 
@@ -48,33 +60,85 @@ func main() {
 
 	printVersion()
 
-	// Create an OVN Controller
-	_, err := ovn.NewOvnController(nil)
+	// Get a config to talk to the apiserver
+	cfg, err := config.GetConfig()
 	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
-	//Initialize all the controllers that are supported here
 
-	// Get a config to talk to the apiserver
-	cfg, err := config.GetConfig()
+	// Create a new Cmd to provide shared dependencies and start components
+	mgr, err := manager.New(cfg, manager.Options{
+		LeaderElection:          *leaderElect,
+		LeaderElectionID:        "nfn-operator-lock",
+		LeaderElectionNamespace: *leaderElectionNamespace,
+		// Metrics and webhooks are served by the leader only
+		MetricsBindAddress: "0",
+	})
 	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
 
-	// Start GRPC Notification Server
-	go notif.SetupNotifServer(cfg)
+	kc, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		namespace = "kube-system"
+	}
+	podName := os.Getenv("POD_NAME")
+	if podName != "" {
+		// The pod of a restarted container may still be labelled as leader
+		if err := setLeaderLabel(kc, namespace, podName, false); err != nil {
+			log.Error(err, "Unable to remove the leader label")
+			os.Exit(1)
+		}
+	}
 
-	// Create a new Cmd to provide shared dependencies and start components
-	mgr, err := manager.New(cfg, manager.Options{})
+	// OVN Controller and GRPC Notification Server run only on the leader
+	err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		// Create an OVN Controller
+		if _, err := ovn.NewOvnController(nil); err != nil {
+			return err
+		}
+		if podName != "" {
+			if err := setLeaderLabel(kc, namespace, podName, true); err != nil {
+				return err
+			}
+		}
+		return notif.SetupNotifServer(cfg, stop)
+	}))
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		return serveMetrics(*metricsAddr, stop)
+	}))
 	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
+	webhookServer := &webhook.Server{Port: *webhookPort, CertDir: *webhookCertDir}
+	if err := mgr.SetFields(webhookServer); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	if err := mgr.Add(manager.RunnableFunc(webhookServer.Start)); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
 
 	log.Info("Registering Components.")
 
+	if err := ovn.RegisterIPMetrics(); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
 	// Setup Scheme for all resources
 	if err := apis.AddToScheme(mgr.GetScheme()); err != nil {
 		log.Error(err, "")
@@ -86,6 +150,16 @@ func main() {
 		log.Error(err, "")
 		os.Exit(1)
 	}
+
+	// Setup all Webhooks
+	if err := nfnwebhook.SetupCerts(cfg, *webhookCertDir, namespace); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	if err := nfnwebhook.AddToManager(&leaderManager{Manager: mgr, webhookServer: webhookServer}); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
 	log.Info("Starting the Cmd.")
 
 	// Start the Cmd
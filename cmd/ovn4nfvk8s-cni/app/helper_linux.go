@@ -3,13 +3,14 @@
 package app
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"os/exec"
 	"ovn4nfv-k8s-plugin/internal/pkg/config"
 	"ovn4nfv-k8s-plugin/internal/pkg/network"
 	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
-	"strconv"
 	"strings"
 
 	"github.com/containernetworking/cni/pkg/types/current"
@@ -98,7 +99,14 @@ func CreateNodeOVSInternalPort(nodeintfipaddr, nodeintfmacaddr, node string) err
 	return nil
 }
 
-func setupInterface(netns ns.NetNS, containerID, ifName, macAddress, ipAddress, gatewayIP, defaultGateway string, idx, mtu int, isDefaultGW bool) (*current.Interface, *current.Interface, error) {
+// hostVethName returns the name of the host end of a pod interface. Names
+// are unique per sandbox and interface, and fit in IFNAMSIZ.
+func hostVethName(containerID, ifName string) string {
+	h := sha1.Sum([]byte(containerID + "/" + ifName))
+	return "ovn" + hex.EncodeToString(h[:])[:12]
+}
+
+func setupInterface(netns ns.NetNS, containerID, ifName, macAddress, ipAddress, gatewayIP, defaultGateway string, mtu int, isDefaultGW bool) (*current.Interface, *current.Interface, error) {
 	hostIface := &current.Interface{}
 	contIface := &current.Interface{}
 	var hostNet string
@@ -177,7 +185,13 @@ func setupInterface(netns ns.NetNS, containerID, ifName, macAddress, ipAddress,
 	}
 
 	// rename the host end of veth pair
-	hostIface.Name = containerID[:14] + strconv.Itoa(idx)
+	hostIface.Name = hostVethName(containerID, ifName)
+	if link, err := netlink.LinkByName(hostIface.Name); err == nil {
+		// Left over by a failed ADD of the same interface
+		if err := netlink.LinkDel(link); err != nil {
+			return nil, nil, fmt.Errorf("failed to delete stale %s: %v", hostIface.Name, err)
+		}
+	}
 	if err := renameLink(oldHostVethName, hostIface.Name); err != nil {
 		return nil, nil, fmt.Errorf("failed to rename %s to %s: %v", oldHostVethName, hostIface.Name, err)
 	}
@@ -186,7 +200,7 @@ func setupInterface(netns ns.NetNS, containerID, ifName, macAddress, ipAddress,
 }
 
 // ConfigureInterface sets up the container interface
-var ConfigureInterface = func(containerNetns, containerID, ifName, namespace, podName, macAddress, ipAddress, gatewayIP, interfaceName, defaultGateway string, idx, mtu int, isDefaultGW bool) ([]*current.Interface, error) {
+var ConfigureInterface = func(containerNetns, containerID, ifName, namespace, podName, macAddress, ipAddress, gatewayIP, interfaceName, defaultGateway string, mtu int, isDefaultGW bool) ([]*current.Interface, error) {
 	netns, err := ns.GetNS(containerNetns)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open netns %q: %v", containerNetns, err)
@@ -200,14 +214,15 @@ var ConfigureInterface = func(containerNetns, containerID, ifName, namespace, po
 		ifaceID = fmt.Sprintf("%s_%s", namespace, podName)
 		interfaceName = ifName
 	}
-	hostIface, contIface, err := setupInterface(netns, containerID, interfaceName, macAddress, ipAddress, gatewayIP, defaultGateway, idx, mtu, isDefaultGW)
+	hostIface, contIface, err := setupInterface(netns, containerID, interfaceName, macAddress, ipAddress, gatewayIP, defaultGateway, mtu, isDefaultGW)
 	if err != nil {
 		return nil, err
 	}
 
 	ovsArgs := []string{
-		"add-port", "br-int", hostIface.Name, "--", "set",
+		"--may-exist", "add-port", "br-int", hostIface.Name, "--", "set",
 		"interface", hostIface.Name,
+		fmt.Sprintf("external_ids:container_ifname=%s", interfaceName),
 		fmt.Sprintf("external_ids:attached_mac=%s", macAddress),
 		fmt.Sprintf("external_ids:iface-id=%s", ifaceID),
 		fmt.Sprintf("external_ids:ip_address=%s", ipAddress),
@@ -249,18 +264,71 @@ var ConfigureRoute = func(containerNetns, dst, gw, dev string) error {
 	return err
 }
 
-// PlatformSpecificCleanup deletes the OVS port
-func PlatformSpecificCleanup(ifaceName string) (bool, error) {
-	done := false
-	ovsArgs := []string{
-		"del-port", "br-int", ifaceName,
+// SandboxPorts returns the OVS ports plugged in br-int for the sandbox
+func SandboxPorts(containerID string) ([]string, error) {
+	stdout, stderr, err := ovn.RunOVSVsctl("--no-heading", "--data=bare", "--columns=name",
+		"find", "Interface", fmt.Sprintf("external_ids:sandbox=%s", containerID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find OVS ports of sandbox %s: %v\n  %q", containerID, err, stderr)
 	}
-	out, err := exec.Command("ovs-vsctl", ovsArgs...).CombinedOutput()
-	if err != nil && !strings.Contains(string(out), "no port named") {
-		// DEL should be idempotent; don't return an error just log it
-		logrus.Warningf("failed to delete OVS port %s: %v\n  %q", ifaceName, err, string(out))
-		done = true
+	return strings.Fields(stdout), nil
+}
+
+// SandboxInterfacePorts returns the OVS ports of the pod interface ifName of
+// the sandbox
+func SandboxInterfacePorts(containerID, ifName string) ([]string, error) {
+	stdout, stderr, err := ovn.RunOVSVsctl("--no-heading", "--data=bare", "--columns=name",
+		"find", "Interface", fmt.Sprintf("external_ids:sandbox=%s", containerID),
+		fmt.Sprintf("external_ids:container_ifname=%s", ifName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find OVS ports of interface %s of sandbox %s: %v\n  %q", ifName, containerID, err, stderr)
 	}
+	return strings.Fields(stdout), nil
+}
 
-	return done, nil
+// FlushConntrack deletes the conntrack entries of the pod addresses from the
+// host, so that a pod reusing an address does not get the replies of the
+// flows of the deleted pod. Addresses without entries are not an error.
+var FlushConntrack = func(ips []net.IP) error {
+	for _, podIP := range ips {
+		family := netlink.InetFamily(netlink.FAMILY_V4)
+		if podIP.To4() == nil {
+			family = netlink.FAMILY_V6
+		}
+		for _, tp := range []netlink.ConntrackFilterType{netlink.ConntrackOrigSrcIP, netlink.ConntrackOrigDstIP} {
+			filter := &netlink.ConntrackFilter{}
+			if err := filter.AddIP(tp, podIP); err != nil {
+				return err
+			}
+			if _, err := netlink.ConntrackDeleteFilter(netlink.ConntrackTable, family, filter); err != nil {
+				return fmt.Errorf("failed to delete conntrack entries of %s: %v", podIP, err)
+			}
+		}
+	}
+	return nil
+}
+
+// PlatformSpecificCleanup deletes the OVS port and the host end of its veth
+// pair. Deleting the host end removes the pod end along with its routes.
+// Ports or links already gone are not an error, DEL must be idempotent.
+func PlatformSpecificCleanup(ifaceName string) error {
+	_, stderr, err := ovn.RunOVSVsctl("--if-exists", "del-port", "br-int", ifaceName)
+	if err != nil {
+		return fmt.Errorf("failed to delete OVS port %s: %v\n  %q", ifaceName, err, stderr)
+	}
+
+	link, err := netlink.LinkByName(ifaceName)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); ok {
+			return nil
+		}
+		return fmt.Errorf("failed to lookup %s: %v", ifaceName, err)
+	}
+	if err := netlink.LinkDel(link); err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); ok {
+			return nil
+		}
+		return fmt.Errorf("failed to delete %s: %v", ifaceName, err)
+	}
+	return nil
 }
@@ -0,0 +1,43 @@
+// +build linux
+
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestApp(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CNI App Test Suite")
+}
+
+var _ = Describe("Host veth names", func() {
+	It("fit in IFNAMSIZ for any number of interfaces", func() {
+		sandbox := "4a5bc3e1f0d9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3"
+		for i := 0; i < 100; i++ {
+			name := hostVethName(sandbox, fmt.Sprintf("net%d", i))
+			Expect(len(name)).To(BeNumerically("<=", 15))
+			Expect(name).To(HavePrefix("ovn"))
+		}
+	})
+
+	It("are stable for the sandbox and interface", func() {
+		Expect(hostVethName("4a5bc3e1f0d9a8", "net1")).To(Equal(hostVethName("4a5bc3e1f0d9a8", "net1")))
+	})
+
+	It("differ between sandboxes sharing a prefix and between interfaces", func() {
+		names := map[string]bool{}
+		for _, sandbox := range []string{"4a5bc3e1f0d9a8b7", "4a5bc3e1f0d9a8c7", "4a5bc3e1f0d9a8"} {
+			for i := 0; i < 20; i++ {
+				name := hostVethName(sandbox, fmt.Sprintf("net%d", i))
+				Expect(names).NotTo(HaveKey(name))
+				names[name] = true
+			}
+		}
+		Expect(hostVethName("4a5bc3e1f0d9a8", "1net")).NotTo(Equal(hostVethName("4a5bc3e1f0d9a81", "net")))
+	})
+})
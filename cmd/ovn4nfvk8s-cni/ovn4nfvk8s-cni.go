@@ -9,9 +9,7 @@ import (
 	cni "ovn4nfv-k8s-plugin/internal/pkg/cnishim"
 	"ovn4nfv-k8s-plugin/internal/pkg/config"
 
-	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types"
-	"github.com/containernetworking/cni/pkg/version"
 	"github.com/containernetworking/plugins/pkg/utils/buildversion"
 )
 
@@ -28,12 +26,7 @@ func main() {
 		if _, err := config.InitConfig(ctx); err != nil {
 			return err
 		}
-		skel.PluginMain(
-			ep.CmdAdd,
-			ep.CmdCheck,
-			ep.CmdDel,
-			version.All,
-			buildversion.BuildString("ovn4nfv-k8s shim cni"))
+		ep.PluginMain(buildversion.BuildString("ovn4nfv-k8s shim cni"))
 
 		return nil
 	}
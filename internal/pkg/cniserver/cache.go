@@ -0,0 +1,123 @@
+package cniserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/containernetworking/cni/pkg/types/current"
+	"k8s.io/klog"
+)
+
+// ResultCacheDir holds the result of each attachment returned at ADD, for
+// DEL, CHECK and GC to know what was configured
+const ResultCacheDir string = "/var/run/ovn4nfv-k8s-plugin/results"
+
+// cachedResult is the result of an attachment of the plugin, without the
+// previous result of a chained plugin
+type cachedResult struct {
+	ContainerID  string          `json:"containerID"`
+	IfName       string          `json:"ifName"`
+	Netns        string          `json:"netns"`
+	NetworkName  string          `json:"networkName"`
+	PodNamespace string          `json:"podNamespace"`
+	PodName      string          `json:"podName"`
+	Result       *current.Result `json:"result"`
+}
+
+func resultFile(containerID, ifName string) string {
+	return filepath.Join(ResultCacheDir, containerID+"_"+ifName)
+}
+
+// saveResult caches the result of the attachment of the request
+func (cr *CNIServerRequest) saveResult(result *current.Result) error {
+	if err := os.MkdirAll(ResultCacheDir, 0700); err != nil {
+		return err
+	}
+	cached := cachedResult{
+		ContainerID:  cr.SandboxID,
+		IfName:       cr.IfName,
+		Netns:        cr.Netns,
+		NetworkName:  cr.CNIConf.Name,
+		PodNamespace: cr.PodNamespace,
+		PodName:      cr.PodName,
+		Result:       result,
+	}
+	b, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	tmp := resultFile(cr.SandboxID, cr.IfName) + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, resultFile(cr.SandboxID, cr.IfName))
+}
+
+// sameAttachment returns whether the request is for the cached attachment
+func (cr *CNIServerRequest) sameAttachment(cached *cachedResult) bool {
+	return cached.ContainerID == cr.SandboxID && cached.IfName == cr.IfName && cached.Netns == cr.Netns &&
+		cached.NetworkName == cr.CNIConf.Name && cached.PodNamespace == cr.PodNamespace && cached.PodName == cr.PodName
+}
+
+func readResult(containerID, ifName string) (*cachedResult, error) {
+	b, err := ioutil.ReadFile(resultFile(containerID, ifName))
+	if err != nil {
+		return nil, err
+	}
+	var cached cachedResult
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return nil, err
+	}
+	return &cached, nil
+}
+
+func deleteResult(containerID, ifName string) {
+	if err := os.Remove(resultFile(containerID, ifName)); err != nil && !os.IsNotExist(err) {
+		klog.Warningf("Failed to remove cached result of %s/%s: %v", containerID, ifName, err)
+	}
+}
+
+// listResults returns the cached results of all the attachments
+func listResults() ([]*cachedResult, error) {
+	files, err := ioutil.ReadDir(ResultCacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var results []*cachedResult
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) == ".tmp" {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(ResultCacheDir, f.Name()))
+		if err != nil {
+			klog.Warningf("Failed to read cached result %s: %v", f.Name(), err)
+			continue
+		}
+		var cached cachedResult
+		if err := json.Unmarshal(b, &cached); err != nil {
+			klog.Warningf("Failed to parse cached result %s: %v", f.Name(), err)
+			continue
+		}
+		results = append(results, &cached)
+	}
+	return results, nil
+}
+
+// hostInterfaces returns the host side interfaces of the result
+func hostInterfaces(result *current.Result) []string {
+	var names []string
+	if result == nil {
+		return names
+	}
+	for _, iface := range result.Interfaces {
+		if iface.Sandbox == "" && iface.Name != "" {
+			names = append(names, iface.Name)
+		}
+	}
+	return names
+}
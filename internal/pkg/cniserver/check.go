@@ -0,0 +1,253 @@
+package cniserver
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/containernetworking/cni/pkg/types"
+	"github.com/containernetworking/cni/pkg/types/current"
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/vishvananda/netlink"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/kube"
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
+)
+
+// Error codes of CHECK, codes below 100 are reserved by the CNI spec
+const (
+	// ErrCheckAnnotation is returned when the pod annotation can't be used
+	ErrCheckAnnotation uint = 100
+	// ErrCheckInterface is returned when a pod interface doesn't match the annotation
+	ErrCheckInterface uint = 101
+	// ErrCheckHostPort is returned when the host end of an interface isn't plugged in br-int
+	ErrCheckHostPort uint = 102
+	// ErrCheckOVNPort is returned when the logical port of an interface isn't up
+	ErrCheckOVNPort uint = 103
+)
+
+func checkError(code uint, msg string, format string, args ...interface{}) *types.Error {
+	return &types.Error{Code: code, Msg: msg, Details: fmt.Sprintf(format, args...)}
+}
+
+// cmdCheck verifies that the interfaces and routes configured at ADD are
+// still in place
+func (cr *CNIServerRequest) cmdCheck(kclient kubernetes.Interface) ([]byte, error) {
+	klog.Infof("ovn4nfvk8s-cni: cmdCheck for pod %s/%s", cr.PodNamespace, cr.PodName)
+	if cr.PodNamespace == "" || cr.PodName == "" {
+		return nil, checkError(ErrCheckAnnotation, "required CNI variable missing", "pod namespace and name must be set")
+	}
+	kubecli := &kube.Kube{KClient: kclient}
+	annotation, err := kubecli.GetAnnotationsOnPod(cr.PodNamespace, cr.PodName)
+	if err != nil {
+		return nil, checkError(ErrCheckAnnotation, "failed to get pod annotations", "%v", err)
+	}
+	interfaces, gateways, err := cr.attachedInterfaces(annotation[ovn4nfvAnnotationTag])
+	if err != nil {
+		return nil, checkError(ErrCheckAnnotation, "failed to parse pod annotation", "%v", err)
+	}
+	var routes []podnetwork.Route
+	if ovnRouteAnnotation, ok := annotation[podnetwork.RoutesAnnotation]; ok && cr.Network == "" {
+		routes, err = podnetwork.ParseRoutes(ovnRouteAnnotation)
+		if err != nil {
+			return nil, checkError(ErrCheckAnnotation, "failed to parse pod routes annotation", "%v", err)
+		}
+	}
+
+	netns, err := ns.GetNS(cr.Netns)
+	if err != nil {
+		return nil, checkError(ErrCheckInterface, "failed to open netns", "%q: %v", cr.Netns, err)
+	}
+	defer netns.Close()
+
+	for i, ovnNet := range interfaces {
+		ifaceID := fmt.Sprintf("%s_%s_%s", cr.PodNamespace, cr.PodName, ovnNet.Interface)
+		ifName := ovnNet.Interface
+		if ifName == podnetwork.DefaultInterface {
+			ifaceID = fmt.Sprintf("%s_%s", cr.PodNamespace, cr.PodName)
+			ifName = cr.IfName
+		}
+		if err := netns.Do(func(_ ns.NetNS) error {
+			return checkInterface(ifName, ovnNet, gateways[i].defaultGateway, cr.mtu())
+		}); err != nil {
+			return nil, err
+		}
+		if err := cr.checkHostPort(ifaceID); err != nil {
+			return nil, err
+		}
+		if err := checkOVNPort(ifaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, route := range routes {
+		if err := netns.Do(func(_ ns.NetNS) error {
+			return checkRoute(route.Dev, route.Dst, route.GW)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err := cr.checkPrevResult(); err != nil {
+		return nil, err
+	}
+	return []byte{}, nil
+}
+
+// checkPrevResult verifies that the result the runtime passes includes the
+// interfaces returned at ADD
+func (cr *CNIServerRequest) checkPrevResult() error {
+	cached, err := readResult(cr.SandboxID, cr.IfName)
+	if err != nil {
+		// Pods added before results were cached
+		klog.Infof("ovn4nfvk8s-cni: cmdCheck no cached result for %s/%s: %v", cr.SandboxID, cr.IfName, err)
+		return nil
+	}
+	if cr.CNIConf.PrevResult == nil {
+		return checkError(ErrCheckInterface, "prevResult missing", "CHECK of %s/%s needs the result of ADD", cr.SandboxID, cr.IfName)
+	}
+	prev, err := current.GetResult(cr.CNIConf.PrevResult)
+	if err != nil {
+		return checkError(ErrCheckInterface, "invalid prevResult", "%v", err)
+	}
+	for _, iface := range cached.Result.Interfaces {
+		found := false
+		for _, p := range prev.Interfaces {
+			if p.Name == iface.Name && p.Sandbox == iface.Sandbox && strings.EqualFold(p.Mac, iface.Mac) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return checkError(ErrCheckInterface, "interface missing from prevResult", "%+v", *iface)
+		}
+	}
+	return nil
+}
+
+// checkInterface verifies the MAC, IP, MTU and default route of a pod
+// interface. It runs in the pod netns.
+func checkInterface(ifName string, ovnNet podnetwork.InterfaceResult, defaultGateway string, mtu int) error {
+	link, err := netlink.LinkByName(ifName)
+	if err != nil {
+		return checkError(ErrCheckInterface, "interface not found", "%s: %v", ifName, err)
+	}
+	attrs := link.Attrs()
+	if !strings.EqualFold(attrs.HardwareAddr.String(), ovnNet.MacAddress) {
+		return checkError(ErrCheckInterface, "interface MAC mismatch",
+			"%s has %s, expected %s", ifName, attrs.HardwareAddr, ovnNet.MacAddress)
+	}
+	if attrs.MTU != mtu {
+		return checkError(ErrCheckInterface, "interface MTU mismatch",
+			"%s has %d, expected %d", ifName, attrs.MTU, mtu)
+	}
+
+	expected, err := netlink.ParseAddr(ovnNet.IPAddress)
+	if err != nil {
+		return checkError(ErrCheckAnnotation, "invalid IP address in pod annotation", "%q: %v", ovnNet.IPAddress, err)
+	}
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return checkError(ErrCheckInterface, "failed to list interface addresses", "%s: %v", ifName, err)
+	}
+	found := false
+	for _, addr := range addrs {
+		if addr.IPNet.String() == expected.IPNet.String() {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return checkError(ErrCheckInterface, "interface IP address missing", "%s has no %s", ifName, expected.IPNet)
+	}
+
+	if defaultGateway == "true" {
+		return checkRoute(ifName, "", ovnNet.GatewayIP)
+	}
+	return nil
+}
+
+// checkRoute verifies that the route to dst through gw on dev is installed,
+// an empty dst is the default route. It runs in the pod netns.
+func checkRoute(dev, dst, gw string) error {
+	link, err := netlink.LinkByName(dev)
+	if err != nil {
+		return checkError(ErrCheckInterface, "route interface not found", "%s: %v", dev, err)
+	}
+	var dstNet *net.IPNet
+	if dst != "" {
+		_, dstNet, err = net.ParseCIDR(dst)
+		if err != nil {
+			return checkError(ErrCheckAnnotation, "invalid route destination in pod annotation", "%q: %v", dst, err)
+		}
+	}
+	gwIP := net.ParseIP(gw)
+	if gwIP == nil {
+		return checkError(ErrCheckAnnotation, "invalid gateway in pod annotation", "%q", gw)
+	}
+	routes, err := netlink.RouteList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return checkError(ErrCheckInterface, "failed to list routes", "%s: %v", dev, err)
+	}
+	for _, r := range routes {
+		if sameDst(r.Dst, dstNet) && r.Gw.Equal(gwIP) {
+			return nil
+		}
+		// Multipath routes installed by network chaining
+		for _, nh := range r.MultiPath {
+			if sameDst(r.Dst, dstNet) && nh.Gw.Equal(gwIP) {
+				return nil
+			}
+		}
+	}
+	if dstNet == nil {
+		dst = "default"
+	}
+	return checkError(ErrCheckInterface, "route missing", "%s via %s dev %s", dst, gw, dev)
+}
+
+func sameDst(a, b *net.IPNet) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.String() == b.String()
+}
+
+// checkHostPort verifies that the host end of the interface is plugged in
+// br-int for the sandbox
+func (cr *CNIServerRequest) checkHostPort(ifaceID string) error {
+	stdout, stderr, err := ovn.RunOVSVsctl("--no-heading", "--data=bare", "--columns=name", "find", "Interface",
+		fmt.Sprintf("external_ids:iface-id=%s", ifaceID),
+		fmt.Sprintf("external_ids:sandbox=%s", cr.SandboxID))
+	if err != nil {
+		return checkError(ErrCheckHostPort, "failed to find OVS interface", "%s: %v %q", ifaceID, err, stderr)
+	}
+	names := strings.Fields(stdout)
+	if len(names) != 1 {
+		return checkError(ErrCheckHostPort, "OVS interface not found",
+			"expected one interface for %s in sandbox %s, found %d", ifaceID, cr.SandboxID, len(names))
+	}
+	bridge, stderr, err := ovn.RunOVSVsctl("port-to-br", names[0])
+	if err != nil {
+		return checkError(ErrCheckHostPort, "OVS port not on a bridge", "%s: %v %q", names[0], err, stderr)
+	}
+	if bridge != "br-int" {
+		return checkError(ErrCheckHostPort, "OVS port on wrong bridge", "%s is on %s, expected br-int", names[0], bridge)
+	}
+	return nil
+}
+
+// checkOVNPort verifies that the logical switch port of the interface is
+// bound to a chassis
+func checkOVNPort(ifaceID string) error {
+	up, stderr, err := ovn.RunOVNNbctl("--if-exists", "get", "logical_switch_port", ifaceID, "up")
+	if err != nil {
+		return checkError(ErrCheckOVNPort, "failed to get logical switch port", "%s: %v %q", ifaceID, err, stderr)
+	}
+	if up != "true" {
+		return checkError(ErrCheckOVNPort, "logical switch port not up", "%s", ifaceID)
+	}
+	return nil
+}
@@ -1,11 +1,12 @@
 package cniserver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -14,31 +15,19 @@ import (
 	"ovn4nfv-k8s-plugin/cmd/ovn4nfvk8s-cni/app"
 	"ovn4nfv-k8s-plugin/internal/pkg/config"
 	"ovn4nfv-k8s-plugin/internal/pkg/kube"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
 
 	"github.com/containernetworking/cni/pkg/types"
 	"github.com/containernetworking/cni/pkg/types/current"
 	"k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/kubernetes"
 )
 
 const (
-	ovn4nfvAnnotationTag = "k8s.plugin.opnfv.org/ovnInterfaces"
+	ovn4nfvAnnotationTag = podnetwork.InterfacesAnnotation
 )
 
-func parseOvnNetworkObject(ovnnetwork string) ([]map[string]string, error) {
-	var ovnNet []map[string]string
-
-	if ovnnetwork == "" {
-		return nil, fmt.Errorf("parseOvnNetworkObject:error")
-	}
-
-	if err := json.Unmarshal([]byte(ovnnetwork), &ovnNet); err != nil {
-		return nil, fmt.Errorf("parseOvnNetworkObject: failed to load ovn network err: %v | ovn network: %v", err, ovnnetwork)
-	}
-
-	return ovnNet, nil
-}
-
 func mergeWithResult(srcObj, dstObj types.Result) (types.Result, error) {
 
 	if dstObj == nil {
@@ -91,12 +80,49 @@ func isNotFoundError(err error) bool {
 	return ok && statusErr.Status().Code == http.StatusNotFound
 }
 
+// ifaceGateway is how an interface of the annotation is configured as the
+// default gateway of the pod
+type ifaceGateway struct {
+	defaultGateway string // "true" when the default route goes through the interface
+	isDefaultGW    bool   // An annotated interface holds the default route
+}
+
+// defaultGateways picks the interface holding the default route of the pod,
+// the first annotated interface asking for it or else the primary interface
+func defaultGateways(interfaces []podnetwork.InterfaceResult, ifName string) []ifaceGateway {
+	var gateways []ifaceGateway
+	var isDefaultGW bool
+	for _, ovnNet := range interfaces {
+		interfaceName := ovnNet.Interface
+		defaultGateway := ovnNet.DefaultGateway
+
+		if interfaceName != podnetwork.DefaultInterface && defaultGateway == "true" && isDefaultGW == false {
+			isDefaultGW = true
+		} else if interfaceName != podnetwork.DefaultInterface && defaultGateway == "true" {
+			defaultGateway = "false"
+		}
+
+		if interfaceName == podnetwork.DefaultInterface && isDefaultGW == true {
+			defaultGateway = "false"
+		}
+
+		if interfaceName == podnetwork.DefaultInterface && isDefaultGW == false {
+			defaultGateway = "true"
+		}
+
+		if interfaceName == podnetwork.DefaultInterface && ifName != "eth0" {
+			defaultGateway = "false"
+		}
+		gateways = append(gateways, ifaceGateway{defaultGateway: defaultGateway, isDefaultGW: isDefaultGW})
+	}
+	return gateways
+}
+
 func (cr *CNIServerRequest) addMultipleInterfaces(ovnAnnotation, namespace, podName string) types.Result {
 	klog.Infof("ovn4nfvk8s-cni: addMultipleInterfaces ")
-	var ovnAnnotatedMap []map[string]string
-	ovnAnnotatedMap, err := parseOvnNetworkObject(ovnAnnotation)
+	interfaces, gateways, err := cr.attachedInterfaces(ovnAnnotation)
 	if err != nil {
-		klog.Errorf("addLogicalPort : Error Parsing Ovn Network List %v %v", ovnAnnotatedMap, err)
+		klog.Errorf("addLogicalPort : Error Parsing Ovn Network List %v", err)
 		return nil
 	}
 	if namespace == "" || podName == "" {
@@ -104,48 +130,28 @@ func (cr *CNIServerRequest) addMultipleInterfaces(ovnAnnotation, namespace, podN
 		return nil
 	}
 	var interfacesArray []*current.Interface
-	var index int
 	var result *current.Result
 	var dstResult types.Result
-	var isDefaultGW bool
-	for _, ovnNet := range ovnAnnotatedMap {
-		ipAddress := ovnNet["ip_address"]
-		macAddress := ovnNet["mac_address"]
-		gatewayIP := ovnNet["gateway_ip"]
-		defaultGateway := ovnNet["defaultGateway"]
+	for i, ovnNet := range interfaces {
+		ipAddress := ovnNet.IPAddress
+		macAddress := ovnNet.MacAddress
+		gatewayIP := ovnNet.GatewayIP
+		defaultGateway := gateways[i].defaultGateway
+		isDefaultGW := gateways[i].isDefaultGW
 
 		if ipAddress == "" || macAddress == "" {
 			klog.Errorf("failed in pod annotation key extract")
 			return nil
 		}
 
-		index++
-		interfaceName := ovnNet["interface"]
+		interfaceName := ovnNet.Interface
 		if interfaceName == "" {
 			klog.Errorf("addMultipleInterfaces: interface can't be null")
 			return nil
 		}
 
-		if interfaceName != "*" && defaultGateway == "true" && isDefaultGW == false {
-			isDefaultGW = true
-		} else if interfaceName != "*" && defaultGateway == "true" {
-			defaultGateway = "false"
-		}
-
-		if interfaceName == "*" && isDefaultGW == true {
-			defaultGateway = "false"
-		}
-
-		if interfaceName == "*" && isDefaultGW == false {
-			defaultGateway = "true"
-		}
-
-		if interfaceName == "*" && cr.IfName != "eth0" {
-			defaultGateway = "false"
-		}
-
 		klog.Infof("addMultipleInterfaces: ipAddress-%v ovn4nfv-interface-%v cni-ifname-%v", ipAddress, interfaceName, cr.IfName)
-		interfacesArray, err = app.ConfigureInterface(cr.Netns, cr.SandboxID, cr.IfName, namespace, podName, macAddress, ipAddress, gatewayIP, interfaceName, defaultGateway, index, config.Default.MTU, isDefaultGW)
+		interfacesArray, err = app.ConfigureInterface(cr.Netns, cr.SandboxID, cr.IfName, namespace, podName, macAddress, ipAddress, gatewayIP, interfaceName, defaultGateway, cr.mtu(), isDefaultGW)
 		if err != nil {
 			klog.Errorf("Failed to configure interface in pod: %v", err)
 			return nil
@@ -203,8 +209,7 @@ func (cr *CNIServerRequest) addMultipleInterfaces(ovnAnnotation, namespace, podN
 
 func (cr *CNIServerRequest) addRoutes(ovnAnnotation string, dstResult types.Result) types.Result {
 	klog.Infof("ovn4nfvk8s-cni: addRoutes ")
-	var ovnAnnotatedMap []map[string]string
-	ovnAnnotatedMap, err := parseOvnNetworkObject(ovnAnnotation)
+	ovnRoutes, err := podnetwork.ParseRoutes(ovnAnnotation)
 	if err != nil {
 		klog.Errorf("addLogicalPort : Error Parsing Ovn Route List %v", err)
 		return nil
@@ -212,10 +217,10 @@ func (cr *CNIServerRequest) addRoutes(ovnAnnotation string, dstResult types.Resu
 
 	var result types.Result
 	var routes []*types.Route
-	for _, ovnNet := range ovnAnnotatedMap {
-		dst := ovnNet["dst"]
-		gw := ovnNet["gw"]
-		dev := ovnNet["dev"]
+	for _, ovnRoute := range ovnRoutes {
+		dst := ovnRoute.Dst
+		gw := ovnRoute.GW
+		dev := ovnRoute.Dev
 		if dst == "" || gw == "" || dev == "" {
 			klog.Errorf("failed in pod annotation key extract")
 			return nil
@@ -253,13 +258,79 @@ func (cr *CNIServerRequest) cmdAdd(kclient kubernetes.Interface) ([]byte, error)
 		return nil, fmt.Errorf("required CNI variable missing")
 	}
 	klog.Infof("ovn4nfvk8s-cni: cmdAdd for pod podname:%s and namespace:%s", podname, namespace)
+	// Repeated ADD of the attachment returns the result of the first one
+	if cached, err := readResult(cr.SandboxID, cr.IfName); err == nil && cr.sameAttachment(cached) {
+		klog.Infof("ovn4nfvk8s-cni: cmdAdd returning cached result of pod %s/%s", namespace, podname)
+		return cr.encodeResult(cached.Result)
+	}
+	annotation, err := cr.waitForAnnotation(kclient)
+	if err == context.Canceled {
+		return nil, errCancelled(cr)
+	} else if err != nil {
+		return nil, withReason("annotation_wait", fmt.Errorf("failed to get pod annotation - %v", err))
+	}
+
+	klog.Infof("ovn4nfvk8s-cni: cmdAdd Annotation Found ")
+	ovnAnnotation := annotation[ovn4nfvAnnotationTag]
+	if err := validateOvnAnnotation(ovnAnnotation); err != nil {
+		return nil, withReason("malformed_annotation",
+			fmt.Errorf("malformed %s annotation on pod %s/%s: %v", ovn4nfvAnnotationTag, namespace, podname, err))
+	}
+	if _, _, err := cr.attachedInterfaces(ovnAnnotation); err != nil {
+		return nil, withReason("malformed_annotation", err)
+	}
+	result := cr.addMultipleInterfaces(ovnAnnotation, namespace, podname)
+	//Add Routes to the pod if annotation found for routes
+	ovnRouteAnnotation, ok := annotation[podnetwork.RoutesAnnotation]
+	if ok && cr.Network == "" {
+		klog.Infof("ovn4nfvk8s-cni: ovnNetworkRoutes Annotation Found %+v", ovnRouteAnnotation)
+		result = cr.addRoutes(ovnRouteAnnotation, result)
+	}
+
+	if result == nil {
+		klog.Errorf("result struct the ovn4nfv-k8s-plugin cniserver")
+		return nil, withReason("interface_setup", fmt.Errorf("result is nil from cni server response"))
+	}
+	if cr.context().Err() != nil {
+		// The DEL waiting for this ADD removes the interfaces
+		return nil, errCancelled(cr)
+	}
+	ownResult, err := current.GetResult(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert result: %v", err)
+	}
+	responseBytes, err := cr.encodeResult(ownResult)
+	if err != nil {
+		return nil, err
+	}
+	if err := cr.saveResult(ownResult); err != nil {
+		klog.Warningf("Failed to cache result of pod %s/%s: %v", namespace, podname, err)
+	}
+	// Route injection for chaining finds the pod through this record
+	if cr.Network == "" {
+		pn := PodNetns{SandboxID: cr.SandboxID, Netns: cr.Netns, DefaultGW: defaultGateway(result)}
+		if err := saveNetns(namespace, podname, pn); err != nil {
+			klog.Warningf("Failed to record netns of pod %s/%s: %v", namespace, podname, err)
+		}
+	}
+
+	return responseBytes, nil
+}
+
+// waitForAnnotation returns the annotations of the pod once the nfn-operator
+// has set the interfaces of the pod
+func (cr *CNIServerRequest) waitForAnnotation(kclient kubernetes.Interface) (map[string]string, error) {
+	if cr.pods != nil {
+		return cr.pods.waitForAnnotation(cr.context(), cr.PodNamespace, cr.PodName, ovn4nfvAnnotationTag)
+	}
+
+	// Without the pod informer, poll the API server
 	kubecli := &kube.Kube{KClient: kclient}
-	// Get the IP address and MAC address from the API server.
 	var annotationBackoff = wait.Backoff{Duration: 1 * time.Second, Steps: 14, Factor: 1.5, Jitter: 0.1}
 	var annotation map[string]string
 	var err error
-	if err = wait.ExponentialBackoff(annotationBackoff, func() (bool, error) {
-		annotation, err = kubecli.GetAnnotationsOnPod(namespace, podname)
+	if err = backoffUntil(cr.context(), annotationBackoff, func() (bool, error) {
+		annotation, err = kubecli.GetAnnotationsOnPod(cr.PodNamespace, cr.PodName)
 		if err != nil {
 			if isNotFoundError(err) {
 				return false, fmt.Errorf("Error - pod not found - %v", err)
@@ -272,46 +343,183 @@ func (cr *CNIServerRequest) cmdAdd(kclient kubernetes.Interface) ([]byte, error)
 		}
 		return false, nil
 	}); err != nil {
-		return nil, fmt.Errorf("failed to get pod annotation - %v", err)
+		return nil, err
 	}
+	return annotation, nil
+}
 
-	klog.Infof("ovn4nfvk8s-cni: cmdAdd Annotation Found ")
-	ovnAnnotation, ok := annotation[ovn4nfvAnnotationTag]
-	if !ok {
-		return nil, fmt.Errorf("Error while obtaining pod annotations")
+// validateOvnAnnotation checks the interfaces annotation before any of them
+// is plumbed
+func validateOvnAnnotation(ovnAnnotation string) error {
+	interfaces, err := podnetwork.ParseInterfaces(ovnAnnotation)
+	if err != nil {
+		return err
 	}
-	result := cr.addMultipleInterfaces(ovnAnnotation, namespace, podname)
-	//Add Routes to the pod if annotation found for routes
-	ovnRouteAnnotation, ok := annotation["ovnNetworkRoutes"]
-	if ok {
-		klog.Infof("ovn4nfvk8s-cni: ovnNetworkRoutes Annotation Found %+v", ovnRouteAnnotation)
-		result = cr.addRoutes(ovnRouteAnnotation, result)
+	for i, ovnNet := range interfaces {
+		switch {
+		case ovnNet.IPAddress == "":
+			return fmt.Errorf("interface %d has no ip_address", i)
+		case ovnNet.MacAddress == "":
+			return fmt.Errorf("interface %d has no mac_address", i)
+		case ovnNet.Interface == "":
+			return fmt.Errorf("interface %d has no interface", i)
+		}
+		if _, _, err := net.ParseCIDR(ovnNet.IPAddress); err != nil {
+			return fmt.Errorf("interface %d: %v", i, err)
+		}
+		if _, err := net.ParseMAC(ovnNet.MacAddress); err != nil {
+			return fmt.Errorf("interface %d: %v", i, err)
+		}
 	}
+	return nil
+}
 
-	if result == nil {
-		klog.Errorf("result struct the ovn4nfv-k8s-plugin cniserver")
-		return nil, fmt.Errorf("result is nil from cni server response")
+// encodeResult returns the result of the plugin in the version of the
+// configuration. Chained after another plugin, our interfaces come after
+// its ones.
+func (cr *CNIServerRequest) encodeResult(result *current.Result) ([]byte, error) {
+	var fullResult types.Result = result
+	if cr.CNIConf.PrevResult != nil {
+		// Merging shifts the interface indexes of the result, keep the
+		// cached one as is
+		b, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		ownResult, err := current.NewResult(b)
+		if err != nil {
+			return nil, err
+		}
+		fullResult, err = mergeWithResult(ownResult, cr.CNIConf.PrevResult)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge with previous result: %v", err)
+		}
 	}
-
-	responseBytes, err := json.Marshal(result)
+	res, err := current.GetResult(fullResult)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert result: %v", err)
+	}
+	responseBytes, err := config.EncodeResult(res, cr.CNIConf.CNIVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal pod request response: %v", err)
 	}
-
 	return responseBytes, nil
 }
 
-func (cr *CNIServerRequest) cmdDel() ([]byte, error) {
-	klog.Infof("cmdDel ")
-	for i := 0; i < 10; i++ {
-		ifaceName := cr.SandboxID[:14] + strconv.Itoa(i)
-		done, err := app.PlatformSpecificCleanup(ifaceName)
-		if err != nil {
-			klog.Errorf("Teardown error: %v", err)
+// backoffUntil is wait.ExponentialBackoff giving up when ctx is done
+func backoffUntil(ctx context.Context, backoff wait.Backoff, condition wait.ConditionFunc) error {
+	for backoff.Steps > 0 {
+		if ok, err := condition(); err != nil || ok {
+			return err
 		}
-		if done {
+		if backoff.Steps == 1 {
 			break
 		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff.Step()):
+		}
 	}
+	return wait.ErrWaitTimeout
+}
+
+func (cr *CNIServerRequest) cmdDel() ([]byte, error) {
+	klog.Infof("ovn4nfvk8s-cni: cmdDel for pod %s/%s sandbox %s", cr.PodNamespace, cr.PodName, cr.SandboxID)
+	sandboxPorts := func() ([]string, error) {
+		if cr.Network == "" {
+			return app.SandboxPorts(cr.SandboxID)
+		}
+		return app.SandboxInterfacePorts(cr.SandboxID, cr.IfName)
+	}
+	if cr.Network == "" {
+		deleteNetns(cr.PodNamespace, cr.PodName, cr.SandboxID)
+	}
+	ports, err := sandboxPorts()
+	if err != nil {
+		return nil, withReason("ovs", err)
+	}
+	// Host links of the result remain when their OVS port is already gone
+	var ips []net.IP
+	cached, err := readResult(cr.SandboxID, cr.IfName)
+	if err == nil && cached.Result != nil {
+		for _, name := range hostInterfaces(cached.Result) {
+			if !containsString(ports, name) {
+				ports = append(ports, name)
+			}
+		}
+		for _, ipc := range cached.Result.IPs {
+			ips = append(ips, ipc.Address.IP)
+		}
+	}
+	var errs []error
+	for _, port := range ports {
+		if err := app.PlatformSpecificCleanup(port); err != nil {
+			klog.Errorf("Teardown error: %v", err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, withReason("ovs", fmt.Errorf("failed to delete %d of %d ports of sandbox %s: %v",
+			len(errs), len(ports), cr.SandboxID, utilerrors.NewAggregate(errs)))
+	}
+	// A port left behind keeps the logical port bound to the node
+	ports, err = sandboxPorts()
+	if err != nil {
+		return nil, withReason("ovs", err)
+	}
+	if len(ports) > 0 {
+		return nil, withReason("ovs", fmt.Errorf("ports %s of sandbox %s still plugged after delete", strings.Join(ports, ", "), cr.SandboxID))
+	}
+	if err := app.FlushConntrack(ips); err != nil {
+		return nil, withReason("conntrack", err)
+	}
+	deleteResult(cr.SandboxID, cr.IfName)
 	return []byte{}, nil
 }
+
+// attachedInterfaces returns the interfaces of the annotation the request
+// handles along with their default gateway: the interface of the network of
+// the NetworkAttachmentDefinition, or else the interfaces not plumbed through
+// a NetworkAttachmentDefinition. The default gateway is picked among all the
+// interfaces, the calls of the pod agree on it.
+func (cr *CNIServerRequest) attachedInterfaces(ovnAnnotation string) ([]podnetwork.InterfaceResult, []ifaceGateway, error) {
+	all, err := podnetwork.ParseInterfaces(ovnAnnotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	var interfaces []podnetwork.InterfaceResult
+	var gateways []ifaceGateway
+	for i, gateway := range defaultGateways(all, cr.IfName) {
+		intf := all[i]
+		if cr.Network == "" && intf.Network != "" {
+			continue
+		}
+		if cr.Network != "" && (intf.Interface != cr.IfName || intf.Network != cr.Network) {
+			continue
+		}
+		interfaces = append(interfaces, intf)
+		gateways = append(gateways, gateway)
+	}
+	if cr.Network != "" && len(interfaces) == 0 {
+		return nil, nil, fmt.Errorf("pod %s/%s has no interface %s on network %s", cr.PodNamespace, cr.PodName, cr.IfName, cr.Network)
+	}
+	return interfaces, gateways, nil
+}
+
+// mtu returns the MTU of the pod interfaces
+func (cr *CNIServerRequest) mtu() int {
+	if cr.MTU > 0 {
+		return cr.MTU
+	}
+	return config.Default.MTU
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
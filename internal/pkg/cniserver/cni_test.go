@@ -0,0 +1,139 @@
+package cniserver
+
+import (
+	"net"
+
+	"ovn4nfv-k8s-plugin/cmd/ovn4nfvk8s-cni/app"
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
+	ovntest "ovn4nfv-k8s-plugin/internal/pkg/testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	fakeexec "k8s.io/utils/exec/testing"
+)
+
+var _ = Describe("Attached interfaces", func() {
+	var annotation string
+
+	BeforeEach(func() {
+		var err error
+		annotation, err = podnetwork.MarshalInterfaces([]podnetwork.InterfaceResult{
+			{IPAddress: "172.16.44.2/24", MacAddress: "0a:00:00:00:00:02", GatewayIP: "172.16.44.1", Interface: "net0",
+				DefaultGateway: "false"},
+			{IPAddress: "172.16.33.2/24", MacAddress: "0a:00:00:00:00:03", GatewayIP: "172.16.33.1", Interface: "net1",
+				DefaultGateway: "true", Network: "ovn-priv-net"},
+			{IPAddress: "10.233.64.5/18", MacAddress: "0a:00:00:00:00:01", GatewayIP: "10.233.64.1", Interface: "*",
+				DefaultGateway: "false"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	interfaceNames := func(interfaces []podnetwork.InterfaceResult) []string {
+		var names []string
+		for _, intf := range interfaces {
+			names = append(names, intf.Interface)
+		}
+		return names
+	}
+
+	It("leaves the interfaces of the definitions to their calls", func() {
+		primary := &CNIServerRequest{PodNamespace: "default", PodName: "pod", IfName: "eth0"}
+		interfaces, gateways, err := primary.attachedInterfaces(annotation)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(interfaceNames(interfaces)).To(Equal([]string{"net0", "*"}))
+		// The default route goes through the interface of the definition
+		Expect(gateways).To(Equal([]ifaceGateway{
+			{defaultGateway: "false", isDefaultGW: false},
+			{defaultGateway: "false", isDefaultGW: true},
+		}))
+
+		delegate := &CNIServerRequest{PodNamespace: "default", PodName: "pod", IfName: "net1", Network: "ovn-priv-net"}
+		interfaces, gateways, err = delegate.attachedInterfaces(annotation)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(interfaceNames(interfaces)).To(Equal([]string{"net1"}))
+		Expect(gateways).To(Equal([]ifaceGateway{{defaultGateway: "true", isDefaultGW: true}}))
+	})
+
+	It("rejects the interfaces of another network", func() {
+		for _, req := range []*CNIServerRequest{
+			{PodNamespace: "default", PodName: "pod", IfName: "net1", Network: "ovn-port-net"},
+			{PodNamespace: "default", PodName: "pod", IfName: "net0", Network: "ovn-priv-net"},
+		} {
+			_, _, err := req.attachedInterfaces(annotation)
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
+
+var _ = Describe("Pod teardown", func() {
+	const (
+		findPorts     = "ovs-vsctl --timeout=15 --no-heading --data=bare --columns=name find Interface external_ids:sandbox=sandbox1"
+		findInterface = findPorts + " external_ids:container_ifname=net1"
+	)
+	var flushed int
+
+	savedFlushConntrack := app.FlushConntrack
+	BeforeEach(func() {
+		flushed = 0
+		app.FlushConntrack = func(ips []net.IP) error {
+			flushed++
+			return nil
+		}
+	})
+	AfterEach(func() {
+		app.FlushConntrack = savedFlushConntrack
+	})
+
+	// runDel runs DEL with the OVS commands and their output
+	runDel := func(req *CNIServerRequest, cmds []ovntest.ExpectedCmd) error {
+		fexec := &fakeexec.FakeExec{
+			LookPathFunc: func(file string) (string, error) {
+				return "/fake-bin/" + file, nil
+			},
+		}
+		for i := range cmds {
+			fexec.CommandScript = ovntest.AddFakeCmd(fexec.CommandScript, &cmds[i])
+		}
+		Expect(ovn.SetExec(fexec)).To(Succeed())
+		_, err := req.cmdDel()
+		Expect(fexec.CommandCalls).To(Equal(len(cmds)))
+		return err
+	}
+
+	It("deletes every port of the sandbox and succeeds when repeated", func() {
+		req := &CNIServerRequest{PodNamespace: "default", PodName: "pod", IfName: "eth0", SandboxID: "sandbox1"}
+		Expect(runDel(req, []ovntest.ExpectedCmd{
+			{Cmd: findPorts, Output: "ovnaaaaaaaaaaaa\novnbbbbbbbbbbbb"},
+			{Cmd: "ovs-vsctl --timeout=15 --if-exists del-port br-int ovnaaaaaaaaaaaa"},
+			{Cmd: "ovs-vsctl --timeout=15 --if-exists del-port br-int ovnbbbbbbbbbbbb"},
+			{Cmd: findPorts},
+		})).To(Succeed())
+		Expect(runDel(req, []ovntest.ExpectedCmd{
+			{Cmd: findPorts},
+			{Cmd: findPorts},
+		})).To(Succeed())
+		Expect(flushed).To(Equal(2))
+	})
+
+	It("deletes the port of the interface of a definition only", func() {
+		req := &CNIServerRequest{PodNamespace: "default", PodName: "pod", IfName: "net1", SandboxID: "sandbox1", Network: "ovn-priv-net"}
+		for i := 0; i < 2; i++ {
+			Expect(runDel(req, []ovntest.ExpectedCmd{
+				{Cmd: findInterface, Output: "ovncccccccccccc"},
+				{Cmd: "ovs-vsctl --timeout=15 --if-exists del-port br-int ovncccccccccccc"},
+				{Cmd: findInterface},
+			})).To(Succeed())
+		}
+	})
+
+	It("fails while a port of the sandbox remains", func() {
+		req := &CNIServerRequest{PodNamespace: "default", PodName: "pod", IfName: "eth0", SandboxID: "sandbox1"}
+		Expect(runDel(req, []ovntest.ExpectedCmd{
+			{Cmd: findPorts, Output: "ovnaaaaaaaaaaaa"},
+			{Cmd: "ovs-vsctl --timeout=15 --if-exists del-port br-int ovnaaaaaaaaaaaa"},
+			{Cmd: findPorts, Output: "ovnaaaaaaaaaaaa"},
+		})).NotTo(Succeed())
+		Expect(flushed).To(BeZero())
+	})
+})
@@ -1,6 +1,7 @@
 package cniserver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -10,16 +11,19 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
 	"k8s.io/klog"
 
 	"ovn4nfv-k8s-plugin/internal/pkg/config"
+	"ovn4nfv-k8s-plugin/internal/pkg/metrics"
 
 	"github.com/containernetworking/cni/pkg/types"
 	"github.com/gorilla/mux"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	utilwait "k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 const CNIServerRunDir string = "/var/run/ovn4nfv-k8s-plugin/cniserver"
@@ -31,6 +35,9 @@ type CNIcommand string
 const CNIAdd CNIcommand = "ADD"
 const CNIUpdate CNIcommand = "UPDATE"
 const CNIDel CNIcommand = "DEL"
+const CNICheck CNIcommand = "CHECK"
+const CNIGC CNIcommand = "GC"
+const CNIStatus CNIcommand = "STATUS"
 
 type CNIServerRequest struct {
 	Command      CNIcommand
@@ -40,6 +47,20 @@ type CNIServerRequest struct {
 	Netns        string
 	IfName       string
 	CNIConf      *types.NetConf
+	// ValidAttachments are the attachments kept by GC
+	ValidAttachments []Attachment
+	// Network is set when the configuration is the one of a generated
+	// NetworkAttachmentDefinition, the request then only handles the pod
+	// interface IfName of the network
+	Network string
+	// MTU of the pod interfaces set by the configuration
+	MTU      int
+	ctx      context.Context
+	pods     *podWatcher
+	recorder record.EventRecorder
+	// requests serializes the requests GC generates with the ones of the
+	// runtime for the same sandbox
+	requests *sandboxRequests
 }
 
 type cniServerRequestFunc func(request *CNIServerRequest, k8sclient kubernetes.Interface) ([]byte, error)
@@ -53,6 +74,9 @@ type CNIServer struct {
 	requestFunc  cniServerRequestFunc
 	serverrundir string
 	k8sclient    kubernetes.Interface
+	requests     *sandboxRequests
+	pods         *podWatcher
+	recorder     record.EventRecorder
 }
 
 func NewCNIServer(serverRunSir string, k8sclient kubernetes.Interface) *CNIServer {
@@ -68,6 +92,7 @@ func NewCNIServer(serverRunSir string, k8sclient kubernetes.Interface) *CNIServe
 		},
 		serverrundir: serverRunSir,
 		k8sclient:    k8sclient,
+		requests:     newSandboxRequests(),
 	}
 	router.NotFoundHandler = http.HandlerFunc(http.NotFound)
 	router.HandleFunc("/", cs.handleCNIShimRequest).Methods("POST")
@@ -100,6 +125,10 @@ func loadCNIRequestToCNIServer(r *CNIEndpointRequest) (*CNIServerRequest, error)
 	cnishimreq := &CNIServerRequest{
 		Command: CNIcommand(cmd),
 	}
+	if cnishimreq.Command == CNIGC || cnishimreq.Command == CNIStatus {
+		// Network wide commands have no attachment
+		return cnishimreq, cnishimreq.loadNetConf(r.NetConfig)
+	}
 
 	cnishimreq.SandboxID, ok = r.ArgEnv["CNI_CONTAINERID"]
 	if !ok {
@@ -131,13 +160,39 @@ func loadCNIRequestToCNIServer(r *CNIEndpointRequest) (*CNIServerRequest, error)
 		return nil, fmt.Errorf("cnishim req missing K8S_POD_NAME")
 	}
 
-	netconf, err := config.ConfigureNetConf(r.NetConfig)
+	if err := cnishimreq.loadNetConf(r.NetConfig); err != nil {
+		return nil, err
+	}
+	return cnishimreq, nil
+}
+
+func (cr *CNIServerRequest) loadNetConf(netConfig []byte) error {
+	netconf, err := config.ConfigureNetConf(netConfig)
 	if err != nil {
-		return nil, fmt.Errorf("cnishim req CNI arg configuration failed:%v", err)
+		return fmt.Errorf("cnishim req CNI arg configuration failed:%v", err)
 	}
+	cr.CNIConf = netconf
 
-	cnishimreq.CNIConf = netconf
-	return cnishimreq, nil
+	var attachment struct {
+		Network string `json:"network"`
+		MTU     int    `json:"mtu"`
+	}
+	if err := json.Unmarshal(netConfig, &attachment); err != nil {
+		return fmt.Errorf("cnishim req network attachment failed:%v", err)
+	}
+	cr.Network = attachment.Network
+	cr.MTU = attachment.MTU
+
+	if cr.Command == CNIGC {
+		var gc struct {
+			ValidAttachments []Attachment `json:"cni.dev/valid-attachments"`
+		}
+		if err := json.Unmarshal(netConfig, &gc); err != nil {
+			return fmt.Errorf("cnishim req GC valid attachments failed:%v", err)
+		}
+		cr.ValidAttachments = gc.ValidAttachments
+	}
+	return nil
 }
 
 func (cs *CNIServer) handleCNIShimRequest(w http.ResponseWriter, r *http.Request) {
@@ -155,8 +210,20 @@ func (cs *CNIServer) handleCNIShimRequest(w http.ResponseWriter, r *http.Request
 	}
 
 	klog.Infof("Waiting for %s result for CNI server pod %s/%s", req.Command, req.PodNamespace, req.PodName)
-	result, err := cs.requestFunc(req, cs.k8sclient)
-	if err != nil {
+	req.pods = cs.pods
+	req.recorder = cs.recorder
+	req.requests = cs.requests
+	result, err := cs.requests.run(req, func() ([]byte, error) {
+		return cs.requestFunc(req, cs.k8sclient)
+	})
+	if cniErr, ok := err.(*types.Error); ok {
+		// The shim returns CNI errors to the runtime as is
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(cniErr); err != nil {
+			klog.Warningf("Error writing %s HTTP response: %v", req.Command, err)
+		}
+	} else if err != nil {
 		http.Error(w, fmt.Sprintf("%v", err), http.StatusBadRequest)
 	} else {
 		w.Header().Set("Content-Type", "application/json")
@@ -171,14 +238,31 @@ func HandleCNIcommandRequest(request *CNIServerRequest, k8sclient kubernetes.Int
 	var err error
 	klog.Infof("[PodNamespace:%s/PodName:%s] dispatching pod network request %v", request.PodNamespace, request.PodName, request)
 	klog.Infof("k8sclient  %s", fmt.Sprintf("%v", k8sclient))
+	start := time.Now()
 	switch request.Command {
 	case CNIAdd:
 		result, err = request.cmdAdd(k8sclient)
+		if err != nil {
+			request.recordAddFailure(k8sclient, err)
+		}
 	case CNIDel:
 		result, err = request.cmdDel()
+	case CNICheck:
+		result, err = request.cmdCheck(k8sclient)
+	case CNIGC:
+		result, err = request.cmdGC()
+	case CNIStatus:
+		result, err = request.cmdStatus()
 	default:
 	}
 	klog.Infof("[PodNamespace:%s/PodName:%s] CNI request %v, result %q, err %v", request.PodNamespace, request.PodName, request, string(result), err)
+	metrics.CNIRequestDuration.WithLabelValues(string(request.Command)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.CNIRequestErrors.WithLabelValues(string(request.Command), errorReason(err)).Inc()
+	}
+	if cniErr, ok := err.(*types.Error); ok {
+		return nil, cniErr
+	}
 	if err != nil {
 		return nil, fmt.Errorf("[PodNamespace:%s/PodName:%s] CNI request %v %v", request.PodNamespace, request.PodName, request, err)
 	}
@@ -0,0 +1,38 @@
+package cniserver
+
+import (
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+// SetEventRecorder makes the server emit an event on the pod when the
+// interfaces of the pod can't be set up
+func (cs *CNIServer) SetEventRecorder(recorder record.EventRecorder) {
+	cs.recorder = recorder
+}
+
+// recordAddFailure emits a warning event on the pod of a failed ADD
+func (cr *CNIServerRequest) recordAddFailure(k8sclient kubernetes.Interface, err error) {
+	if cr.recorder == nil || errorReason(err) == "cancelled" {
+		return
+	}
+	pod, getErr := cr.getPod(k8sclient)
+	if getErr != nil {
+		klog.Warningf("Unable to record event on pod %s/%s: %v", cr.PodNamespace, cr.PodName, getErr)
+		return
+	}
+	cr.recorder.Eventf(pod, kapi.EventTypeWarning, "AddInterfaceFailed",
+		"Failed to set up interface %s of sandbox %s: %v", cr.IfName, cr.SandboxID, err)
+}
+
+// getPod returns the pod of the request from the cache of the pods of the
+// node when available
+func (cr *CNIServerRequest) getPod(k8sclient kubernetes.Interface) (*kapi.Pod, error) {
+	if cr.pods != nil {
+		return cr.pods.lister.Pods(cr.PodNamespace).Get(cr.PodName)
+	}
+	return k8sclient.CoreV1().Pods(cr.PodNamespace).Get(cr.PodName, metav1.GetOptions{})
+}
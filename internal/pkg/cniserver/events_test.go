@@ -0,0 +1,46 @@
+package cniserver
+
+import (
+	"fmt"
+
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ADD failure events", func() {
+	var client *fake.Clientset
+	var recorder *record.FakeRecorder
+	var req *CNIServerRequest
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset(&kapi.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"},
+		})
+		recorder = record.NewFakeRecorder(1)
+		req = &CNIServerRequest{
+			Command:      CNIAdd,
+			PodNamespace: "default",
+			PodName:      "pod",
+			SandboxID:    "sandbox",
+			IfName:       "eth0",
+			recorder:     recorder,
+		}
+	})
+
+	It("emits a warning on the pod", func() {
+		req.recordAddFailure(client, withReason("annotation_wait", fmt.Errorf("not annotated")))
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring("AddInterfaceFailed"),
+			ContainSubstring("not annotated"))))
+	})
+
+	It("ignores cancelled requests", func() {
+		req.recordAddFailure(client, errCancelled(req))
+		Expect(recorder.Events).NotTo(Receive())
+	})
+})
@@ -0,0 +1,76 @@
+package cniserver
+
+import (
+	"fmt"
+
+	"github.com/containernetworking/cni/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+)
+
+// ErrPluginNotAvailable is returned by STATUS when pods can't be added
+const ErrPluginNotAvailable uint = 50
+
+// Attachment is an attachment of the network still in use, passed to GC
+type Attachment struct {
+	ContainerID string `json:"containerID"`
+	IfName      string `json:"ifname"`
+}
+
+// cmdGC tears down the cached attachments of the network the runtime no
+// longer knows about. Each attachment is deleted as a DEL of its sandbox
+// would, after the requests of the runtime for the sandbox.
+func (cr *CNIServerRequest) cmdGC() ([]byte, error) {
+	requests := cr.requests
+	if requests == nil {
+		requests = newSandboxRequests()
+	}
+	klog.Infof("ovn4nfvk8s-cni: cmdGC for network %s, %d valid attachments", cr.CNIConf.Name, len(cr.ValidAttachments))
+	valid := make(map[Attachment]bool)
+	for _, a := range cr.ValidAttachments {
+		valid[a] = true
+	}
+	cached, err := listResults()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached results: %v", err)
+	}
+	var errs []error
+	for _, c := range cached {
+		if c.NetworkName != cr.CNIConf.Name || valid[Attachment{ContainerID: c.ContainerID, IfName: c.IfName}] {
+			continue
+		}
+		klog.Infof("ovn4nfvk8s-cni: cmdGC removing attachment %s/%s of pod %s/%s", c.ContainerID, c.IfName, c.PodNamespace, c.PodName)
+		req := &CNIServerRequest{
+			Command:      CNIDel,
+			PodNamespace: c.PodNamespace,
+			PodName:      c.PodName,
+			SandboxID:    c.ContainerID,
+			IfName:       c.IfName,
+			CNIConf:      cr.CNIConf,
+			Network:      cr.Network,
+		}
+		req.pods = cr.pods
+		req.recorder = cr.recorder
+		if _, err := requests.run(req, req.cmdDel); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return []byte{}, nil
+}
+
+// cmdStatus reports whether the node is ready to add pods
+func (cr *CNIServerRequest) cmdStatus() ([]byte, error) {
+	if _, stderr, err := ovn.RunOVSVsctl("br-exists", "br-int"); err != nil {
+		return nil, &types.Error{
+			Code:    ErrPluginNotAvailable,
+			Msg:     "integration bridge br-int not available",
+			Details: fmt.Sprintf("%v %q", err, stderr),
+		}
+	}
+	return []byte{}, nil
+}
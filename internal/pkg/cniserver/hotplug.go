@@ -0,0 +1,69 @@
+package cniserver
+
+import (
+	"fmt"
+
+	"k8s.io/klog"
+
+	"ovn4nfv-k8s-plugin/cmd/ovn4nfvk8s-cni/app"
+	"ovn4nfv-k8s-plugin/internal/pkg/config"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
+)
+
+// WithPodNetns runs f with the network namespace recorded for the pod at CNI
+// ADD, serialized with the CNI requests of its sandbox. It returns false
+// without running f when the pod has no network namespace on the node.
+func (cs *CNIServer) WithPodNetns(namespace, podName string, f func(pn *PodNetns) error) (bool, error) {
+	return cs.requests.withPodNetns(namespace, podName, f)
+}
+
+// HotplugInterface plugs the interface in the network namespace recorded for
+// the pod at CNI ADD. The default route of the pod is left alone. An interface
+// already plugged, by the ADD of a pod annotated in the meantime, is kept. The
+// MTU of the overlay networks is used when mtu is 0.
+func (cs *CNIServer) HotplugInterface(namespace, podName string, intf podnetwork.InterfaceResult, mtu int) error {
+	found, err := cs.WithPodNetns(namespace, podName, func(pn *PodNetns) error {
+		ports, err := app.SandboxInterfacePorts(pn.SandboxID, intf.Interface)
+		if err != nil {
+			return err
+		}
+		if len(ports) > 0 {
+			klog.Infof("Interface %s of pod %s/%s already plugged", intf.Interface, namespace, podName)
+			return nil
+		}
+		if intf.IPAddress == "" || intf.MacAddress == "" {
+			return fmt.Errorf("interface %s of pod %s/%s has no address", intf.Interface, namespace, podName)
+		}
+		if mtu == 0 {
+			mtu = config.Default.MTU
+		}
+		_, err = app.ConfigureInterface(pn.Netns, pn.SandboxID, "eth0", namespace, podName, intf.MacAddress,
+			intf.IPAddress, intf.GatewayIP, intf.Interface, "false", mtu, false)
+		return err
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("no network namespace recorded for pod %s/%s", namespace, podName)
+	}
+	return err
+}
+
+// HotunplugInterface removes the interface from the pod. An interface already
+// gone, along with the sandbox of the pod, is not an error.
+func (cs *CNIServer) HotunplugInterface(namespace, podName, intf string) error {
+	found, err := cs.WithPodNetns(namespace, podName, func(pn *PodNetns) error {
+		ports, err := app.SandboxInterfacePorts(pn.SandboxID, intf)
+		if err != nil {
+			return err
+		}
+		for _, port := range ports {
+			if err := app.PlatformSpecificCleanup(port); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil && !found {
+		klog.Infof("Pod %s/%s netns not found, skip removal of interface %s", namespace, podName, intf)
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package cniserver
+
+import (
+	"github.com/containernetworking/cni/pkg/types"
+)
+
+// cniError is an error of a CNI request with the reason counted in metrics
+type cniError struct {
+	reason string
+	err    error
+}
+
+func (e *cniError) Error() string {
+	return e.err.Error()
+}
+
+func withReason(reason string, err error) error {
+	return &cniError{reason: reason, err: err}
+}
+
+// errorReason returns the reason of a failed CNI request
+func errorReason(err error) string {
+	switch e := err.(type) {
+	case *cniError:
+		return e.reason
+	case *types.Error:
+		switch e.Code {
+		case ErrCheckAnnotation:
+			return "annotation"
+		case ErrCheckInterface:
+			return "interface"
+		case ErrCheckHostPort:
+			return "host_port"
+		case ErrCheckOVNPort:
+			return "ovn_port"
+		case ErrPluginNotAvailable:
+			return "not_available"
+		}
+	}
+	return "internal"
+}
@@ -0,0 +1,88 @@
+package cniserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/containernetworking/cni/pkg/types"
+	"github.com/containernetworking/cni/pkg/types/current"
+	"k8s.io/klog"
+)
+
+// NetnsRunDir holds the network namespace of each pod seen at CNI ADD. It is
+// outside of the CNI server run dir so that it survives an agent restart.
+const NetnsRunDir string = "/var/run/ovn4nfv-k8s-plugin/netns"
+
+// PodNetns is the network namespace of a pod sandbox as seen by the runtime
+type PodNetns struct {
+	SandboxID string `json:"sandboxID"`
+	Netns     string `json:"netns"`
+	// DefaultGW is the default gateway configured at ADD, chaining routes
+	// restore it when they are removed
+	DefaultGW string `json:"defaultGW,omitempty"`
+}
+
+func netnsFile(namespace, podName string) string {
+	return filepath.Join(NetnsRunDir, namespace+"_"+podName)
+}
+
+// saveNetns records the network namespace path of the pod sandbox. The path
+// is the one the runtime passed at ADD, which works for docker, containerd
+// and CRI-O alike.
+func saveNetns(namespace, podName string, pn PodNetns) error {
+	if err := os.MkdirAll(NetnsRunDir, 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(pn)
+	if err != nil {
+		return err
+	}
+	tmp := netnsFile(namespace, podName) + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, netnsFile(namespace, podName))
+}
+
+// deleteNetns removes the record of the pod if it belongs to the sandbox
+func deleteNetns(namespace, podName, sandboxID string) {
+	pn, err := readNetns(namespace, podName)
+	if err != nil {
+		return
+	}
+	if pn.SandboxID != sandboxID {
+		// Pod has a new sandbox
+		return
+	}
+	if err := os.Remove(netnsFile(namespace, podName)); err != nil && !os.IsNotExist(err) {
+		klog.Warningf("Failed to remove netns record of pod %s/%s: %v", namespace, podName, err)
+	}
+}
+
+func readNetns(namespace, podName string) (*PodNetns, error) {
+	b, err := ioutil.ReadFile(netnsFile(namespace, podName))
+	if err != nil {
+		return nil, err
+	}
+	var pn PodNetns
+	if err := json.Unmarshal(b, &pn); err != nil {
+		return nil, err
+	}
+	return &pn, nil
+}
+
+// defaultGateway returns the gateway of the default route in the result
+func defaultGateway(result types.Result) string {
+	res, err := current.NewResultFromResult(result)
+	if err != nil {
+		return ""
+	}
+	for _, r := range res.Routes {
+		if ones, _ := r.Dst.Mask.Size(); ones == 0 && r.GW != nil {
+			return r.GW.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package cniserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// DefaultAddTimeout is how long ADD waits for the nfn-operator to annotate
+// the pod
+const DefaultAddTimeout = 2 * time.Minute
+
+// podWatcher caches the pods of the node, ADD waits for the pod annotation
+// on informer events instead of polling the API server
+type podWatcher struct {
+	nodeName string
+	timeout  time.Duration
+	lister   corelisters.PodLister
+
+	sync.Mutex
+	// waiters are notified of the changes of the pod they wait for
+	waiters map[string][]chan struct{}
+}
+
+// WatchNodePods starts the informer of the pods of the node and waits for
+// its cache to sync. ADD gives up when the pod isn't annotated within
+// addTimeout.
+func (cs *CNIServer) WatchNodePods(nodeName string, addTimeout time.Duration, stopCh <-chan struct{}) error {
+	if nodeName == "" {
+		return fmt.Errorf("node name required to watch pods")
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(cs.k8sclient, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+		}))
+	podInformer := factory.Core().V1().Pods()
+	w := &podWatcher{
+		nodeName: nodeName,
+		timeout:  addTimeout,
+		lister:   podInformer.Lister(),
+		waiters:  make(map[string][]chan struct{}),
+	}
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.notify,
+		UpdateFunc: func(_, obj interface{}) { w.notify(obj) },
+		DeleteFunc: w.notify,
+	})
+	factory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, podInformer.Informer().HasSynced) {
+		return fmt.Errorf("failed to sync pods of node %s", nodeName)
+	}
+	cs.pods = w
+	return nil
+}
+
+func (w *podWatcher) notify(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	w.Lock()
+	defer w.Unlock()
+	for _, ch := range w.waiters[key] {
+		close(ch)
+	}
+	delete(w.waiters, key)
+}
+
+// changed returns a channel closed on the next change of the pod
+func (w *podWatcher) changed(key string) <-chan struct{} {
+	w.Lock()
+	defer w.Unlock()
+	ch := make(chan struct{})
+	w.waiters[key] = append(w.waiters[key], ch)
+	return ch
+}
+
+// waitForAnnotation returns the annotations of the pod once it has the
+// annotation key
+func (w *podWatcher) waitForAnnotation(ctx context.Context, namespace, name, key string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	podKey := namespace + "/" + name
+	var pod *kapi.Pod
+	for {
+		// Register before reading the cache not to miss a change
+		changed := w.changed(podKey)
+		var err error
+		pod, err = w.lister.Pods(namespace).Get(name)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if pod != nil {
+			if _, ok := pod.Annotations[key]; ok {
+				return pod.Annotations, nil
+			}
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			if ctx.Err() == context.Canceled {
+				return nil, ctx.Err()
+			}
+			if pod == nil {
+				return nil, fmt.Errorf("pod %s not found on node %s within %v", podKey, w.nodeName, w.timeout)
+			}
+			klog.Warningf("Pod %s not annotated within %v", podKey, w.timeout)
+			return nil, fmt.Errorf("nfn-operator did not annotate pod %s with %s within %v", podKey, key, w.timeout)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package cniserver
+
+import (
+	"context"
+	"time"
+
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pod watcher", func() {
+	var client *fake.Clientset
+	var cs *CNIServer
+	var stopCh chan struct{}
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset(&kapi.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"},
+			Spec:       kapi.PodSpec{NodeName: "node"},
+		})
+		cs = NewCNIServer("", client)
+		stopCh = make(chan struct{})
+		Expect(cs.WatchNodePods("node", time.Second, stopCh)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		close(stopCh)
+	})
+
+	It("returns the annotations once the pod is annotated", func() {
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(100 * time.Millisecond)
+			pod, err := client.CoreV1().Pods("default").Get("pod", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			pod.Annotations = map[string]string{ovn4nfvAnnotationTag: "[]"}
+			_, err = client.CoreV1().Pods("default").Update(pod)
+			Expect(err).NotTo(HaveOccurred())
+		}()
+		annotations, err := cs.pods.waitForAnnotation(context.Background(), "default", "pod", ovn4nfvAnnotationTag)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(annotations).To(HaveKeyWithValue(ovn4nfvAnnotationTag, "[]"))
+	})
+
+	It("tells an unannotated pod from a missing one", func() {
+		_, err := cs.pods.waitForAnnotation(context.Background(), "default", "pod", ovn4nfvAnnotationTag)
+		Expect(err).To(MatchError(ContainSubstring("did not annotate")))
+		_, err = cs.pods.waitForAnnotation(context.Background(), "default", "other", ovn4nfvAnnotationTag)
+		Expect(err).To(MatchError(ContainSubstring("not found on node")))
+	})
+
+	It("stops waiting when cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := cs.pods.waitForAnnotation(ctx, "default", "pod", ovn4nfvAnnotationTag)
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
@@ -0,0 +1,121 @@
+package cniserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"k8s.io/klog"
+)
+
+// sandboxRequests serializes the requests of each sandbox. Requests of
+// different sandboxes still run concurrently.
+type sandboxRequests struct {
+	sync.Mutex
+	sandboxes map[string]*sandboxState
+}
+
+type sandboxState struct {
+	// lock is held by the request running for the sandbox
+	lock chan struct{}
+	// refs counts the requests running or waiting for the sandbox
+	refs int
+	// adds cancel the ADD requests running or waiting for the sandbox
+	adds map[*CNIServerRequest]context.CancelFunc
+}
+
+func newSandboxRequests() *sandboxRequests {
+	return &sandboxRequests{sandboxes: make(map[string]*sandboxState)}
+}
+
+// run runs the request once the earlier requests of its sandbox are done. A
+// DEL cancels the ADD requests of the sandbox, running or waiting.
+func (s *sandboxRequests) run(req *CNIServerRequest, f func() ([]byte, error)) ([]byte, error) {
+	if req.SandboxID == "" {
+		// GC and STATUS are about the network
+		return f()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req.ctx = ctx
+
+	s.Lock()
+	st, ok := s.sandboxes[req.SandboxID]
+	if !ok {
+		st = &sandboxState{
+			lock: make(chan struct{}, 1),
+			adds: make(map[*CNIServerRequest]context.CancelFunc),
+		}
+		s.sandboxes[req.SandboxID] = st
+	}
+	st.refs++
+	switch req.Command {
+	case CNIAdd:
+		st.adds[req] = cancel
+	case CNIDel:
+		for add, cancelAdd := range st.adds {
+			klog.Infof("Cancelling ADD of pod %s/%s for DEL of sandbox %s", add.PodNamespace, add.PodName, req.SandboxID)
+			cancelAdd()
+		}
+	}
+	s.Unlock()
+
+	defer func() {
+		s.Lock()
+		delete(st.adds, req)
+		st.refs--
+		if st.refs == 0 {
+			delete(s.sandboxes, req.SandboxID)
+		}
+		s.Unlock()
+	}()
+
+	st.lock <- struct{}{}
+	defer func() { <-st.lock }()
+	if err := ctx.Err(); err != nil {
+		return nil, errCancelled(req)
+	}
+	return f()
+}
+
+func errCancelled(req *CNIServerRequest) error {
+	return withReason("cancelled", fmt.Errorf("%s of sandbox %s cancelled by DEL", req.Command, req.SandboxID))
+}
+
+// context returns the context of the request, cancelled when a DEL of the
+// sandbox cancels an ADD
+func (cr *CNIServerRequest) context() context.Context {
+	if cr.ctx == nil {
+		return context.Background()
+	}
+	return cr.ctx
+}
+
+// withPodNetns runs f with the network namespace recorded for the pod, after
+// the requests of its sandbox and before the next ones. It returns false
+// without running f when the pod has no network namespace, or when a DEL of
+// the sandbox removed it or the pod got a new sandbox in the meantime.
+func (s *sandboxRequests) withPodNetns(namespace, podName string, f func(pn *PodNetns) error) (bool, error) {
+	pn, err := readNetns(namespace, podName)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	found := false
+	req := &CNIServerRequest{PodNamespace: namespace, PodName: podName, SandboxID: pn.SandboxID}
+	_, err = s.run(req, func() ([]byte, error) {
+		current, err := readNetns(namespace, podName)
+		if os.IsNotExist(err) || (err == nil && current.SandboxID != pn.SandboxID) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		return nil, f(current)
+	})
+	return found, err
+}
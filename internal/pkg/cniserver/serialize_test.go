@@ -0,0 +1,96 @@
+package cniserver
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCNIServer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CNI Server Test Suite")
+}
+
+var _ = Describe("Sandbox requests", func() {
+	var requests *sandboxRequests
+
+	BeforeEach(func() {
+		requests = newSandboxRequests()
+	})
+
+	It("serializes the requests of a sandbox", func() {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan struct{})
+		add := &CNIServerRequest{Command: CNIAdd, SandboxID: "sandbox"}
+		go func() {
+			defer GinkgoRecover()
+			_, err := requests.run(add, func() ([]byte, error) {
+				close(started)
+				<-release
+				return nil, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}()
+		<-started
+
+		check := &CNIServerRequest{Command: CNICheck, SandboxID: "sandbox"}
+		go func() {
+			defer GinkgoRecover()
+			_, err := requests.run(check, func() ([]byte, error) { return nil, nil })
+			Expect(err).NotTo(HaveOccurred())
+			close(done)
+		}()
+		Consistently(done, 100*time.Millisecond).ShouldNot(BeClosed())
+		close(release)
+		Eventually(done).Should(BeClosed())
+		Eventually(func() int {
+			requests.Lock()
+			defer requests.Unlock()
+			return len(requests.sandboxes)
+		}).Should(Equal(0))
+	})
+
+	It("cancels the ADD of the sandbox on DEL", func() {
+		started := make(chan struct{})
+		cancelled := make(chan struct{})
+		add := &CNIServerRequest{Command: CNIAdd, SandboxID: "sandbox"}
+		go func() {
+			defer GinkgoRecover()
+			_, err := requests.run(add, func() ([]byte, error) {
+				close(started)
+				<-add.context().Done()
+				close(cancelled)
+				return nil, errCancelled(add)
+			})
+			Expect(err).To(HaveOccurred())
+		}()
+		<-started
+
+		del := &CNIServerRequest{Command: CNIDel, SandboxID: "sandbox"}
+		_, err := requests.run(del, func() ([]byte, error) {
+			Expect(cancelled).To(BeClosed())
+			return nil, nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("runs the requests of different sandboxes concurrently", func() {
+		release := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			_, err := requests.run(&CNIServerRequest{Command: CNIAdd, SandboxID: "one"}, func() ([]byte, error) {
+				<-release
+				return nil, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}()
+		_, err := requests.run(&CNIServerRequest{Command: CNIAdd, SandboxID: "two"}, func() ([]byte, error) {
+			return nil, nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		close(release)
+	})
+})
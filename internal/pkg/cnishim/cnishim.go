@@ -14,7 +14,6 @@ import (
 
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types"
-	"github.com/containernetworking/cni/pkg/types/current"
 	"github.com/sirupsen/logrus"
 )
 
@@ -72,6 +71,10 @@ func (ep *Endpoint) sendCNIServerReq(req *cniserver.CNIEndpointRequest) ([]byte,
 	}
 
 	if reponse.StatusCode != 200 {
+		cniErr := &types.Error{}
+		if err := json.Unmarshal(rbody, cniErr); err == nil && cniErr.Code != 0 {
+			return nil, cniErr
+		}
 		return nil, fmt.Errorf("CNI Server request is failed with reponse status %v and reponse body %s", reponse.StatusCode, string(rbody))
 	}
 
@@ -91,16 +94,19 @@ func (ep *Endpoint) CmdAdd(args *skel.CmdArgs) error {
 	if err != nil {
 		return err
 	}
-	result, err := current.NewResult(reponsebody)
-	if err != nil {
+	// The CNI server encodes the result in the version of the configuration
+	if _, err := config.DecodeResult(conf.CNIVersion, reponsebody); err != nil {
 		return fmt.Errorf("failed to unmarshall CNIServer Result reponse %v - err:%v", string(reponsebody), err)
 	}
-
-	return types.PrintResult(result, conf.CNIVersion)
+	_, err = os.Stdout.Write(reponsebody)
+	return err
 }
 
 func (ep *Endpoint) CmdCheck(args *skel.CmdArgs) error {
-	return nil
+	logrus.Infof("ovn4nfvk8s-cni: cmdCheck ")
+	req := cniEndpointRequest(args)
+	_, err := ep.sendCNIServerReq(req)
+	return err
 }
 
 func (ep *Endpoint) CmdDel(args *skel.CmdArgs) error {
@@ -109,3 +115,39 @@ func (ep *Endpoint) CmdDel(args *skel.CmdArgs) error {
 	_, err := ep.sendCNIServerReq(req)
 	return err
 }
+
+// cmdNetwork sends the GC and STATUS commands of CNI 1.1, which are about
+// the network rather than an attachment
+func (ep *Endpoint) cmdNetwork(cmd string) error {
+	logrus.Infof("ovn4nfvk8s-cni: cmd%s ", cmd)
+	stdinData, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("error reading from stdin: %v", err)
+	}
+	req := cniEndpointRequest(&skel.CmdArgs{StdinData: stdinData})
+	_, err = ep.sendCNIServerReq(req)
+	if _, ok := err.(*types.Error); err != nil && !ok && cmd == string(cniserver.CNIStatus) {
+		return &types.Error{Code: cniserver.ErrPluginNotAvailable, Msg: "CNI server not available", Details: err.Error()}
+	}
+	return err
+}
+
+// PluginMain runs the CNI command. The CNI library doesn't know the GC and
+// STATUS commands, they are dispatched here.
+func (ep *Endpoint) PluginMain(about string) {
+	switch cmd := os.Getenv("CNI_COMMAND"); cmd {
+	case string(cniserver.CNIGC), string(cniserver.CNIStatus):
+		if err := ep.cmdNetwork(cmd); err != nil {
+			e, ok := err.(*types.Error)
+			if !ok {
+				e = &types.Error{Code: 100, Msg: err.Error()}
+			}
+			if err := e.Print(); err != nil {
+				logrus.Errorf("Error writing error JSON to stdout: %v", err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	skel.PluginMain(ep.CmdAdd, ep.CmdCheck, ep.CmdDel, config.CNIVersions, about)
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/containernetworking/cni/pkg/types"
+	"github.com/containernetworking/cni/pkg/types/current"
+	"github.com/containernetworking/cni/pkg/version"
+)
+
+// CNIVersions are the CNI spec versions supported by the plugin. The CNI
+// library implements up to 0.4.0, 1.x results differ from 0.4.0 only by
+// the IP version being dropped.
+var CNIVersions = version.PluginSupports("0.1.0", "0.2.0", "0.3.0", "0.3.1", "0.4.0", "1.0.0", "1.1.0")
+
+// IsCNIv1 returns whether the CNI spec version is 1.0.0 or later
+func IsCNIv1(cniVersion string) bool {
+	gte, err := version.GreaterThanOrEqualTo(cniVersion, "1.0.0")
+	return err == nil && gte
+}
+
+// DecodeResult decodes a result of the CNI spec version
+func DecodeResult(cniVersion string, data []byte) (*current.Result, error) {
+	if cniVersion == "" {
+		cniVersion = "0.1.0"
+	}
+	if !IsCNIv1(cniVersion) {
+		r, err := version.NewResult(cniVersion, data)
+		if err != nil {
+			return nil, err
+		}
+		return current.NewResultFromResult(r)
+	}
+	result := &current.Result{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	result.CNIVersion = current.ImplementedSpecVersion
+	for _, ip := range result.IPs {
+		ip.Version = "6"
+		if ip.Address.IP.To4() != nil {
+			ip.Version = "4"
+		}
+	}
+	return result, nil
+}
+
+// EncodeResult encodes the result in the CNI spec version
+func EncodeResult(result *current.Result, cniVersion string) ([]byte, error) {
+	if cniVersion == "" {
+		// Configurations without a version are 0.1.0
+		cniVersion = "0.1.0"
+	}
+	if !IsCNIv1(cniVersion) {
+		r, err := result.GetAsVersion(cniVersion)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(r)
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	m["cniVersion"] = cniVersion
+	if ips, ok := m["ips"].([]interface{}); ok {
+		for _, ip := range ips {
+			if ipc, ok := ip.(map[string]interface{}); ok {
+				delete(ipc, "version")
+			}
+		}
+	}
+	return json.Marshal(m)
+}
+
+// parsePrevResult sets the PrevResult of the network configuration
+func parsePrevResult(conf *types.NetConf) error {
+	if !IsCNIv1(conf.CNIVersion) {
+		return version.ParsePrevResult(conf)
+	}
+	b, err := json.Marshal(conf.RawPrevResult)
+	if err != nil {
+		return fmt.Errorf("could not serialize prevResult: %v", err)
+	}
+	conf.RawPrevResult = nil
+	conf.PrevResult, err = DecodeResult(conf.CNIVersion, b)
+	if err != nil {
+		return fmt.Errorf("could not parse prevResult: %v", err)
+	}
+	return nil
+}
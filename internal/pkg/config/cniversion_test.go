@@ -0,0 +1,68 @@
+package config
+
+import (
+	"encoding/json"
+	"net"
+
+	"github.com/containernetworking/cni/pkg/types/current"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CNI versions", func() {
+	var result *current.Result
+
+	BeforeEach(func() {
+		_, ipnet, _ := net.ParseCIDR("10.154.142.0/18")
+		ipnet.IP = net.ParseIP("10.154.142.5")
+		result = &current.Result{
+			Interfaces: []*current.Interface{{Name: "net0", Mac: "0a:00:00:00:00:01", Sandbox: "/var/run/netns/test"}},
+			IPs: []*current.IPConfig{{
+				Version:   "4",
+				Interface: current.Int(0),
+				Address:   *ipnet,
+				Gateway:   net.ParseIP("10.154.142.1"),
+			}},
+		}
+	})
+
+	It("drops the IP version from 1.0.0 results", func() {
+		b, err := EncodeResult(result, "1.0.0")
+		Expect(err).NotTo(HaveOccurred())
+		var m map[string]interface{}
+		Expect(json.Unmarshal(b, &m)).To(Succeed())
+		Expect(m["cniVersion"]).To(Equal("1.0.0"))
+		Expect(m["ips"].([]interface{})[0]).NotTo(HaveKey("version"))
+	})
+
+	It("keeps the IP version in 0.4.0 results", func() {
+		b, err := EncodeResult(result, "0.4.0")
+		Expect(err).NotTo(HaveOccurred())
+		var m map[string]interface{}
+		Expect(json.Unmarshal(b, &m)).To(Succeed())
+		Expect(m["cniVersion"]).To(Equal("0.4.0"))
+		Expect(m["ips"].([]interface{})[0]).To(HaveKeyWithValue("version", "4"))
+	})
+
+	It("decodes 1.0.0 results", func() {
+		b, err := EncodeResult(result, "1.0.0")
+		Expect(err).NotTo(HaveOccurred())
+		decoded, err := DecodeResult("1.0.0", b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded.IPs[0].Version).To(Equal("4"))
+		Expect(decoded.IPs[0].Address.String()).To(Equal("10.154.142.5/18"))
+		Expect(decoded.Interfaces).To(Equal(result.Interfaces))
+	})
+
+	It("parses a 1.0.0 prevResult", func() {
+		conf, err := ConfigureNetConf([]byte(`{"cniVersion": "1.0.0", "name": "ovn4nfv", "type": "ovn4nfvk8s-cni",
+			"prevResult": {"cniVersion": "1.0.0", "interfaces": [{"name": "eth0"}],
+			"ips": [{"interface": 0, "address": "10.1.0.5/16"}]}}`))
+		Expect(err).NotTo(HaveOccurred())
+		prev, err := current.GetResult(conf.PrevResult)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prev.Interfaces[0].Name).To(Equal("eth0"))
+		Expect(prev.IPs[0].Version).To(Equal("4"))
+	})
+})
@@ -10,7 +10,6 @@ import (
 	"reflect"
 
 	"github.com/containernetworking/cni/pkg/types"
-	"github.com/containernetworking/cni/pkg/version"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	gcfg "gopkg.in/gcfg.v1"
@@ -296,7 +295,7 @@ func ConfigureNetConf(bytes []byte) (*types.NetConf, error) {
 	}
 
 	if conf.RawPrevResult != nil {
-		if err := version.ParsePrevResult(conf); err != nil {
+		if err := parsePrevResult(conf); err != nil {
 			return nil, err
 		}
 	}
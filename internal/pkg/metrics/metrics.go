@@ -0,0 +1,125 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package metrics holds the Prometheus metrics of the nfn-operator and the
+// nfn-agent. They are registered in the controller-runtime registry, which
+// the manager of the nfn-operator serves along with the reconcile metrics of
+// the controllers.
+package metrics
+
+import (
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "ovn4nfv"
+
+var (
+	// CNIRequestDuration is the latency of the CNI requests per command
+	CNIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cni",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the CNI requests per command",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"command"})
+
+	// CNIRequestErrors counts the failed CNI requests per command and reason
+	CNIRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cni",
+		Name:      "request_errors_total",
+		Help:      "Number of failed CNI requests per command and reason",
+	}, []string{"command", "reason"})
+
+	// CommandDuration is the latency of the ovn-nbctl, ovs-vsctl and ip
+	// commands
+	CommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "ovs",
+		Name:      "command_duration_seconds",
+		Help:      "Latency of the OVN, OVS and ip commands",
+	}, []string{"command"})
+
+	// CommandTotal counts the ovn-nbctl, ovs-vsctl and ip commands per result
+	CommandTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ovs",
+		Name:      "commands_total",
+		Help:      "Number of OVN, OVS and ip commands per result",
+	}, []string{"command", "result"})
+
+	// CommandRetries counts the commands retried because the OVN database
+	// refused the connection
+	CommandRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ovs",
+		Name:      "command_retries_total",
+		Help:      "Number of OVN commands retried on connection refused",
+	}, []string{"command"})
+
+	// ConnectedAgents is the number of nfn-agents subscribed to the
+	// notification server
+	ConnectedAgents = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "notify",
+		Name:      "connected_agents",
+		Help:      "Number of nfn-agents subscribed to the notification server",
+	})
+
+	// NotifySendFailures counts the messages that could not be sent to the
+	// nfn-agents per message type
+	NotifySendFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "notify",
+		Name:      "send_failures_total",
+		Help:      "Number of messages that could not be sent to an nfn-agent",
+	}, []string{"message"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		CNIRequestDuration,
+		CNIRequestErrors,
+		CommandDuration,
+		CommandTotal,
+		CommandRetries,
+		ConnectedAgents,
+		NotifySendFailures,
+	)
+}
+
+// ObserveCommand records a run of the command at cmdPath
+func ObserveCommand(cmdPath string, start time.Time, err error) {
+	command := filepath.Base(cmdPath)
+	CommandDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	CommandTotal.WithLabelValues(command, result).Inc()
+}
+
+// Handler serves the metrics of the registry, for binaries without a
+// controller-runtime manager
+func Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,256 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package netattachdef generates the NetworkAttachmentDefinitions Multus
+// uses to attach pods to the ovn4nfv networks. The definitions are labelled
+// with their network, the one in the namespace of the network is also owned by
+// it so that the garbage collector deletes it along with the network.
+package netattachdef
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/config"
+)
+
+var log = logf.Log.WithName("netattachdef")
+
+// Options of the generated definitions, set from the nfn-operator flags
+var (
+	// Enabled turns the generation on
+	Enabled bool
+	// Namespaces are the namespaces of the definitions of the Networks,
+	// the definition of a ProviderNetwork is in its namespace
+	Namespaces = []string{"default"}
+	// MTU of the pod interfaces, the one of the overlay networks by default
+	MTU = config.Default.MTU
+)
+
+// cniPluginType is the CNI plugin of the ovn4nfv cniType
+const cniPluginType = "ovn4nfvk8s-cni"
+
+var gvk = schema.GroupVersionKind{Group: "k8s.cni.cncf.io", Version: "v1", Kind: "NetworkAttachmentDefinition"}
+
+// Labels of the generated definitions identifying their network. Owner
+// references can't cross namespaces, the labels can.
+const (
+	kindLabel      = "k8s.plugin.opnfv.org/network-kind"
+	namespaceLabel = "k8s.plugin.opnfv.org/network-namespace"
+	nameLabel      = "k8s.plugin.opnfv.org/network-name"
+)
+
+// cniConfig is the CNI configuration of a definition. The CNI server only
+// sets up the pod interface of the network when it is called with it.
+type cniConfig struct {
+	CNIVersion string `json:"cniVersion"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Network    string `json:"network"`
+	MTU        int    `json:"mtu"`
+}
+
+// Config returns the CNI configuration of the definition of the network
+func Config(network, cniType string) (string, error) {
+	if cniType != "ovn4nfv" {
+		return "", fmt.Errorf("CNI type %s not supported", cniType)
+	}
+	b, err := json.Marshal(cniConfig{
+		CNIVersion: "0.3.1",
+		Name:       network,
+		Type:       cniPluginType,
+		Network:    network,
+		MTU:        MTU,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Owner is the Network or ProviderNetwork owning definitions
+type Owner interface {
+	metav1.Object
+	runtime.Object
+}
+
+// ownerLabels returns the labels of the definitions of the network
+func ownerLabels(scheme *runtime.Scheme, owner Owner) (map[string]string, error) {
+	ownerGVK, err := apiutil.GVKForObject(owner, scheme)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		kindLabel:      ownerGVK.Kind,
+		namespaceLabel: owner.GetNamespace(),
+		nameLabel:      owner.GetName(),
+	}, nil
+}
+
+// generated tells whether the definition was generated for the network
+func generated(nad *unstructured.Unstructured, labels map[string]string) bool {
+	current := nad.GetLabels()
+	for k, v := range labels {
+		if current[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Sync creates the definitions of the network in the namespaces and updates
+// their CNI configuration. Definitions of the same name not generated for the
+// network are left alone.
+func Sync(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner Owner, cniType string, namespaces []string) error {
+	if !Enabled {
+		return nil
+	}
+	config, err := Config(owner.GetName(), cniType)
+	if err != nil {
+		return err
+	}
+	labels, err := ownerLabels(scheme, owner)
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces {
+		nad := newDefinition()
+		err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: owner.GetName()}, nad)
+		if meta.IsNoMatchError(err) {
+			log.Info("NetworkAttachmentDefinition CRD not installed, definitions not generated")
+			return nil
+		}
+		if errors.IsNotFound(err) {
+			nad = newDefinition()
+			nad.SetNamespace(namespace)
+			nad.SetName(owner.GetName())
+			nad.SetLabels(labels)
+			if namespace == owner.GetNamespace() {
+				if err := controllerutil.SetControllerReference(owner, nad, scheme); err != nil {
+					return err
+				}
+			}
+			if err := unstructured.SetNestedField(nad.Object, config, "spec", "config"); err != nil {
+				return err
+			}
+			log.Info("Creating NetworkAttachmentDefinition", "namespace", namespace, "name", owner.GetName())
+			if err := c.Create(ctx, nad); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !generated(nad, labels) {
+			log.Info("NetworkAttachmentDefinition not generated for the network, not updated", "namespace", namespace, "name", owner.GetName())
+			continue
+		}
+		current, _, _ := unstructured.NestedString(nad.Object, "spec", "config")
+		if current == config {
+			continue
+		}
+		if err := unstructured.SetNestedField(nad.Object, config, "spec", "config"); err != nil {
+			return err
+		}
+		log.Info("Updating NetworkAttachmentDefinition", "namespace", namespace, "name", owner.GetName())
+		if err := c.Update(ctx, nad); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete deletes the definitions of the network in the namespaces. The
+// garbage collector deletes the one in the namespace of the network as well,
+// but only once the network is gone.
+func Delete(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner Owner, namespaces []string) error {
+	labels, err := ownerLabels(scheme, owner)
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces {
+		nad := newDefinition()
+		err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: owner.GetName()}, nad)
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !generated(nad, labels) {
+			continue
+		}
+		log.Info("Deleting NetworkAttachmentDefinition", "namespace", namespace, "name", owner.GetName())
+		if err := c.Delete(ctx, nad); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Network returns the network the NetworkAttachmentDefinition namespace/name
+// attaches pods to. found is false when there is no such definition or the
+// definitions aren't installed, network is empty for the definitions of the
+// other CNI plugins.
+func Network(ctx context.Context, c client.Reader, namespace, name string) (network string, found bool, err error) {
+	nad := newDefinition()
+	err = c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, nad)
+	if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	config, _, _ := unstructured.NestedString(nad.Object, "spec", "config")
+	// A single plugin or a list of chained plugins
+	var conf struct {
+		cniConfig
+		Plugins []cniConfig `json:"plugins"`
+	}
+	if err := json.Unmarshal([]byte(config), &conf); err != nil {
+		log.Info("Invalid NetworkAttachmentDefinition config", "namespace", namespace, "name", name, "error", err.Error())
+		return "", true, nil
+	}
+	for _, plugin := range append([]cniConfig{conf.cniConfig}, conf.Plugins...) {
+		if plugin.Type == cniPluginType {
+			return plugin.Network, true, nil
+		}
+	}
+	return "", true, nil
+}
+
+func newDefinition() *unstructured.Unstructured {
+	nad := &unstructured.Unstructured{}
+	nad.SetGroupVersionKind(gvk)
+	return nad
+}
@@ -0,0 +1,66 @@
+package netattachdef
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNetAttachDef(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NetworkAttachmentDefinition Test Suite")
+}
+
+var _ = Describe("Network attachment definitions", func() {
+	It("configures the CNI with the network", func() {
+		config, err := Config("ovn-priv-net", "ovn4nfv")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(MatchJSON(`{"cniVersion": "0.3.1", "name": "ovn-priv-net", "type": "ovn4nfvk8s-cni",
+			"network": "ovn-priv-net", "mtu": 1400}`))
+	})
+
+	It("rejects the other CNI types", func() {
+		_, err := Config("ovn-priv-net", "flannel")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("matches the definitions generated for the network", func() {
+		labels := map[string]string{kindLabel: "Network", namespaceLabel: "default", nameLabel: "ovn-priv-net"}
+		nad := &unstructured.Unstructured{}
+		Expect(generated(nad, labels)).To(BeFalse())
+		nad.SetLabels(map[string]string{kindLabel: "ProviderNetwork", namespaceLabel: "default", nameLabel: "ovn-priv-net"})
+		Expect(generated(nad, labels)).To(BeFalse())
+		nad.SetLabels(map[string]string{kindLabel: "Network", namespaceLabel: "default", nameLabel: "ovn-priv-net", "app": "test"})
+		Expect(generated(nad, labels)).To(BeTrue())
+	})
+
+	It("resolves the network of the definitions", func() {
+		definition := func(name, config string) runtime.Object {
+			nad := newDefinition()
+			nad.SetNamespace("default")
+			nad.SetName(name)
+			Expect(unstructured.SetNestedField(nad.Object, config, "spec", "config")).To(Succeed())
+			return nad
+		}
+		c := fake.NewFakeClientWithScheme(runtime.NewScheme(),
+			definition("ovn-priv", `{"cniVersion": "0.3.1", "name": "ovn-priv", "type": "ovn4nfvk8s-cni", "network": "ovn-priv-net"}`),
+			definition("ovn-chained", `{"cniVersion": "0.3.1", "name": "ovn-chained", "plugins": [
+				{"type": "ovn4nfvk8s-cni", "network": "ovn-port-net"}, {"type": "tuning"}]}`),
+			definition("macvlan", `{"cniVersion": "0.3.1", "name": "macvlan", "type": "macvlan", "master": "eth0"}`))
+
+		for name, expected := range map[string]string{"ovn-priv": "ovn-priv-net", "ovn-chained": "ovn-port-net", "macvlan": ""} {
+			network, found, err := Network(context.TODO(), c, "default", name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(network).To(Equal(expected))
+		}
+		_, found, err := Network(context.TODO(), c, "default", "ovn-priv-net")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+})
@@ -67,7 +67,7 @@ func GetDefaultGatewayInterface() (*net.Interface, error) {
 func getIfaceAddrs(iface *net.Interface) ([]netlink.Addr, error) {
 
 	link := &netlink.Device{
-		netlink.LinkAttrs{
+		LinkAttrs: netlink.LinkAttrs{
 			Index: iface.Index,
 		},
 	}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nfn
+
+import (
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+)
+
+// sentGenerations holds, for each provider network, the generation of the
+// last create notification sent to each node. The nodes report the
+// generations they applied, which tells how far the provider network is
+// applied. The nodes subscribing get their provider networks in a sync,
+// which is recorded as well: a new leader tracks the provider networks
+// sent by the previous one without sending them again.
+var sentGenerations = struct {
+	sync.Mutex
+	pn map[string]map[string]uint64
+}{pn: make(map[string]map[string]uint64)}
+
+func pnKey(pn *v1alpha1.ProviderNetwork) string {
+	return pnNameKey(pn.Namespace, pn.Name)
+}
+
+func pnNameKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func recordSent(pn *v1alpha1.ProviderNetwork, sent map[string]uint64) {
+	sentGenerations.Lock()
+	defer sentGenerations.Unlock()
+	sentGenerations.pn[pnKey(pn)] = sent
+}
+
+// recordSynced records the generation of the sync sent to the node for
+// each of the provider networks in it
+func recordSynced(keys []string, nodeName string, generation uint64) {
+	sentGenerations.Lock()
+	defer sentGenerations.Unlock()
+	for _, key := range keys {
+		sent, ok := sentGenerations.pn[key]
+		if !ok {
+			sent = make(map[string]uint64)
+			sentGenerations.pn[key] = sent
+		}
+		sent[nodeName] = generation
+	}
+}
+
+func forgetNode(pn *v1alpha1.ProviderNetwork, nodeName string) {
+	sentGenerations.Lock()
+	defer sentGenerations.Unlock()
+	delete(sentGenerations.pn[pnKey(pn)], nodeName)
+}
+
+func forgetSent(pn *v1alpha1.ProviderNetwork) {
+	sentGenerations.Lock()
+	defer sentGenerations.Unlock()
+	delete(sentGenerations.pn, pnKey(pn))
+}
+
+// ProviderNetworkSent returns true if the provider network was sent to the
+// nodes, in a notification or a sync, since the operator started
+func ProviderNetworkSent(pn *v1alpha1.ProviderNetwork) bool {
+	sentGenerations.Lock()
+	defer sentGenerations.Unlock()
+	_, ok := sentGenerations.pn[pnKey(pn)]
+	return ok
+}
+
+// ProviderNetworkProgress returns the number of nodes the provider network
+// was sent to, the number of them that applied it and the nodes that
+// disconnected before applying it. The nodes deleted from the cluster are
+// not counted.
+func ProviderNetworkProgress(pn *v1alpha1.ProviderNetwork) (applied, total int, disconnected []string) {
+	sentGenerations.Lock()
+	sent := make(map[string]uint64, len(sentGenerations.pn[pnKey(pn)]))
+	for name, generation := range sentGenerations.pn[pnKey(pn)] {
+		sent[name] = generation
+	}
+	sentGenerations.Unlock()
+	for name, generation := range sent {
+		client := notifServer.GetClient(name)
+		switch {
+		case client == nil:
+			if !nodeExists(name) {
+				log.Info("Node deleted, stop tracking the provider network", "node name", name, "Provider Network", pnKey(pn))
+				forgetNode(pn, name)
+				continue
+			}
+			disconnected = append(disconnected, name)
+		case client.getApplied() >= generation:
+			applied++
+		}
+		total++
+	}
+	sort.Strings(disconnected)
+	return applied, total, disconnected
+}
+
+// nodeExists returns false if the node was deleted from the cluster
+func nodeExists(name string) bool {
+	_, err := kubeClientset.CoreV1().Nodes().Get(name, v1.GetOptions{})
+	return !errors.IsNotFound(err)
+}
@@ -71,10 +71,16 @@ type Notification struct {
 	//	*Notification_ProviderNwRemove
 	//	*Notification_ContainterRtInsert
 	//	*Notification_ContainterRtRemove
-	Payload              isNotification_Payload `protobuf_oneof:"payload"`
-	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
-	XXX_unrecognized     []byte                 `json:"-"`
-	XXX_sizecache        int32                  `json:"-"`
+	//	*Notification_ProviderNwSync
+	//	*Notification_PodIntfAdd
+	//	*Notification_PodIntfRemove
+	Payload isNotification_Payload `protobuf_oneof:"payload"`
+	// Generation of the node state after this notification is applied.
+	// Generations are contiguous per node, a gap means a lost notification.
+	Generation           uint64   `protobuf:"varint,8,opt,name=generation,proto3" json:"generation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Notification) Reset()         { *m = Notification{} }
@@ -133,6 +139,18 @@ type Notification_ContainterRtRemove struct {
 	ContainterRtRemove *ContainerRouteRemove `protobuf:"bytes,6,opt,name=containter_rt_remove,json=containterRtRemove,proto3,oneof"`
 }
 
+type Notification_ProviderNwSync struct {
+	ProviderNwSync *ProviderNetworkSync `protobuf:"bytes,7,opt,name=provider_nw_sync,json=providerNwSync,proto3,oneof"`
+}
+
+type Notification_PodIntfAdd struct {
+	PodIntfAdd *PodInterfaceAdd `protobuf:"bytes,9,opt,name=pod_intf_add,json=podIntfAdd,proto3,oneof"`
+}
+
+type Notification_PodIntfRemove struct {
+	PodIntfRemove *PodInterfaceRemove `protobuf:"bytes,10,opt,name=pod_intf_remove,json=podIntfRemove,proto3,oneof"`
+}
+
 func (*Notification_InSync) isNotification_Payload() {}
 
 func (*Notification_ProviderNwCreate) isNotification_Payload() {}
@@ -143,6 +161,12 @@ func (*Notification_ContainterRtInsert) isNotification_Payload() {}
 
 func (*Notification_ContainterRtRemove) isNotification_Payload() {}
 
+func (*Notification_ProviderNwSync) isNotification_Payload() {}
+
+func (*Notification_PodIntfAdd) isNotification_Payload() {}
+
+func (*Notification_PodIntfRemove) isNotification_Payload() {}
+
 func (m *Notification) GetPayload() isNotification_Payload {
 	if m != nil {
 		return m.Payload
@@ -185,6 +209,34 @@ func (m *Notification) GetContainterRtRemove() *ContainerRouteRemove {
 	return nil
 }
 
+func (m *Notification) GetProviderNwSync() *ProviderNetworkSync {
+	if x, ok := m.GetPayload().(*Notification_ProviderNwSync); ok {
+		return x.ProviderNwSync
+	}
+	return nil
+}
+
+func (m *Notification) GetPodIntfAdd() *PodInterfaceAdd {
+	if x, ok := m.GetPayload().(*Notification_PodIntfAdd); ok {
+		return x.PodIntfAdd
+	}
+	return nil
+}
+
+func (m *Notification) GetPodIntfRemove() *PodInterfaceRemove {
+	if x, ok := m.GetPayload().(*Notification_PodIntfRemove); ok {
+		return x.PodIntfRemove
+	}
+	return nil
+}
+
+func (m *Notification) GetGeneration() uint64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Notification) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -193,6 +245,9 @@ func (*Notification) XXX_OneofWrappers() []interface{} {
 		(*Notification_ProviderNwRemove)(nil),
 		(*Notification_ContainterRtInsert)(nil),
 		(*Notification_ContainterRtRemove)(nil),
+		(*Notification_ProviderNwSync)(nil),
+		(*Notification_PodIntfAdd)(nil),
+		(*Notification_PodIntfRemove)(nil),
 	}
 }
 
@@ -306,6 +361,47 @@ func (m *ProviderNetworkRemove) GetDirectProviderIntf() string {
 	return ""
 }
 
+// ProviderNetworkSync is the full set of provider networks for a node.
+// Anything not listed here must be removed from the node.
+type ProviderNetworkSync struct {
+	ProviderNw           []*ProviderNetworkCreate `protobuf:"bytes,1,rep,name=provider_nw,json=providerNw,proto3" json:"provider_nw,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *ProviderNetworkSync) Reset()         { *m = ProviderNetworkSync{} }
+func (m *ProviderNetworkSync) String() string { return proto.CompactTextString(m) }
+func (*ProviderNetworkSync) ProtoMessage()    {}
+func (*ProviderNetworkSync) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{4}
+}
+
+func (m *ProviderNetworkSync) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProviderNetworkSync.Unmarshal(m, b)
+}
+func (m *ProviderNetworkSync) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProviderNetworkSync.Marshal(b, m, deterministic)
+}
+func (m *ProviderNetworkSync) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProviderNetworkSync.Merge(m, src)
+}
+func (m *ProviderNetworkSync) XXX_Size() int {
+	return xxx_messageInfo_ProviderNetworkSync.Size(m)
+}
+func (m *ProviderNetworkSync) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProviderNetworkSync.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProviderNetworkSync proto.InternalMessageInfo
+
+func (m *ProviderNetworkSync) GetProviderNw() []*ProviderNetworkCreate {
+	if m != nil {
+		return m.ProviderNw
+	}
+	return nil
+}
+
 type VlanInfo struct {
 	VlanId               string   `protobuf:"bytes,1,opt,name=vlan_id,json=vlanId,proto3" json:"vlan_id,omitempty"`
 	ProviderIntf         string   `protobuf:"bytes,2,opt,name=provider_intf,json=providerIntf,proto3" json:"provider_intf,omitempty"`
@@ -319,7 +415,7 @@ func (m *VlanInfo) Reset()         { *m = VlanInfo{} }
 func (m *VlanInfo) String() string { return proto.CompactTextString(m) }
 func (*VlanInfo) ProtoMessage()    {}
 func (*VlanInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ee04cc9cbb38bc3, []int{4}
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{5}
 }
 
 func (m *VlanInfo) XXX_Unmarshal(b []byte) error {
@@ -372,7 +468,7 @@ func (m *DirectInfo) Reset()         { *m = DirectInfo{} }
 func (m *DirectInfo) String() string { return proto.CompactTextString(m) }
 func (*DirectInfo) ProtoMessage()    {}
 func (*DirectInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ee04cc9cbb38bc3, []int{5}
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{6}
 }
 
 func (m *DirectInfo) XXX_Unmarshal(b []byte) error {
@@ -412,7 +508,7 @@ func (m *RouteData) Reset()         { *m = RouteData{} }
 func (m *RouteData) String() string { return proto.CompactTextString(m) }
 func (*RouteData) ProtoMessage()    {}
 func (*RouteData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ee04cc9cbb38bc3, []int{6}
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{7}
 }
 
 func (m *RouteData) XXX_Unmarshal(b []byte) error {
@@ -447,19 +543,25 @@ func (m *RouteData) GetGw() string {
 	return ""
 }
 
+// Pods are identified by namespace and name, the agent finds the network
+// namespace recorded at CNI ADD. container_id is informational only.
 type ContainerRouteInsert struct {
-	ContainerId          string       `protobuf:"bytes,1,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
-	Route                []*RouteData `protobuf:"bytes,2,rep,name=route,proto3" json:"route,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
-	XXX_unrecognized     []byte       `json:"-"`
-	XXX_sizecache        int32        `json:"-"`
+	ContainerId  string       `protobuf:"bytes,1,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	Route        []*RouteData `protobuf:"bytes,2,rep,name=route,proto3" json:"route,omitempty"`
+	PodNamespace string       `protobuf:"bytes,3,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	PodName      string       `protobuf:"bytes,4,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// namespace/name of the chain, reported back if the routes can't be added
+	Chain                string   `protobuf:"bytes,5,opt,name=chain,proto3" json:"chain,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ContainerRouteInsert) Reset()         { *m = ContainerRouteInsert{} }
 func (m *ContainerRouteInsert) String() string { return proto.CompactTextString(m) }
 func (*ContainerRouteInsert) ProtoMessage()    {}
 func (*ContainerRouteInsert) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ee04cc9cbb38bc3, []int{7}
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{8}
 }
 
 func (m *ContainerRouteInsert) XXX_Unmarshal(b []byte) error {
@@ -494,9 +596,36 @@ func (m *ContainerRouteInsert) GetRoute() []*RouteData {
 	return nil
 }
 
+func (m *ContainerRouteInsert) GetPodNamespace() string {
+	if m != nil {
+		return m.PodNamespace
+	}
+	return ""
+}
+
+func (m *ContainerRouteInsert) GetPodName() string {
+	if m != nil {
+		return m.PodName
+	}
+	return ""
+}
+
+func (m *ContainerRouteInsert) GetChain() string {
+	if m != nil {
+		return m.Chain
+	}
+	return ""
+}
+
+// Only the routes listed are removed while the pod stays in the chain. The
+// route to the host network and the default route are restored when the pod
+// leaves the chain.
 type ContainerRouteRemove struct {
 	ContainerId          string       `protobuf:"bytes,1,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
 	Route                []*RouteData `protobuf:"bytes,2,rep,name=route,proto3" json:"route,omitempty"`
+	PodNamespace         string       `protobuf:"bytes,3,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	PodName              string       `protobuf:"bytes,4,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	LeaveChain           bool         `protobuf:"varint,5,opt,name=leave_chain,json=leaveChain,proto3" json:"leave_chain,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
 	XXX_unrecognized     []byte       `json:"-"`
 	XXX_sizecache        int32        `json:"-"`
@@ -506,7 +635,7 @@ func (m *ContainerRouteRemove) Reset()         { *m = ContainerRouteRemove{} }
 func (m *ContainerRouteRemove) String() string { return proto.CompactTextString(m) }
 func (*ContainerRouteRemove) ProtoMessage()    {}
 func (*ContainerRouteRemove) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ee04cc9cbb38bc3, []int{8}
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{9}
 }
 
 func (m *ContainerRouteRemove) XXX_Unmarshal(b []byte) error {
@@ -541,6 +670,213 @@ func (m *ContainerRouteRemove) GetRoute() []*RouteData {
 	return nil
 }
 
+func (m *ContainerRouteRemove) GetPodNamespace() string {
+	if m != nil {
+		return m.PodNamespace
+	}
+	return ""
+}
+
+func (m *ContainerRouteRemove) GetPodName() string {
+	if m != nil {
+		return m.PodName
+	}
+	return ""
+}
+
+func (m *ContainerRouteRemove) GetLeaveChain() bool {
+	if m != nil {
+		return m.LeaveChain
+	}
+	return false
+}
+
+// PodInterface is an interface of the ovnInterfaces annotation of a pod
+type PodInterface struct {
+	Interface  string `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	IpAddress  string `protobuf:"bytes,2,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	MacAddress string `protobuf:"bytes,3,opt,name=mac_address,json=macAddress,proto3" json:"mac_address,omitempty"`
+	GatewayIp  string `protobuf:"bytes,4,opt,name=gateway_ip,json=gatewayIp,proto3" json:"gateway_ip,omitempty"`
+	// MTU of the interface, the MTU of the overlay networks of the node when 0
+	Mtu                  int32    `protobuf:"varint,5,opt,name=mtu,proto3" json:"mtu,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PodInterface) Reset()         { *m = PodInterface{} }
+func (m *PodInterface) String() string { return proto.CompactTextString(m) }
+func (*PodInterface) ProtoMessage()    {}
+func (*PodInterface) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{10}
+}
+
+func (m *PodInterface) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PodInterface.Unmarshal(m, b)
+}
+func (m *PodInterface) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PodInterface.Marshal(b, m, deterministic)
+}
+func (m *PodInterface) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PodInterface.Merge(m, src)
+}
+func (m *PodInterface) XXX_Size() int {
+	return xxx_messageInfo_PodInterface.Size(m)
+}
+func (m *PodInterface) XXX_DiscardUnknown() {
+	xxx_messageInfo_PodInterface.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PodInterface proto.InternalMessageInfo
+
+func (m *PodInterface) GetInterface() string {
+	if m != nil {
+		return m.Interface
+	}
+	return ""
+}
+
+func (m *PodInterface) GetIpAddress() string {
+	if m != nil {
+		return m.IpAddress
+	}
+	return ""
+}
+
+func (m *PodInterface) GetMacAddress() string {
+	if m != nil {
+		return m.MacAddress
+	}
+	return ""
+}
+
+func (m *PodInterface) GetGatewayIp() string {
+	if m != nil {
+		return m.GatewayIp
+	}
+	return ""
+}
+
+func (m *PodInterface) GetMtu() int32 {
+	if m != nil {
+		return m.Mtu
+	}
+	return 0
+}
+
+// Interfaces hot-plugged in or unplugged from a running pod. The agent finds
+// the network namespace recorded at CNI ADD, a pod not set up yet gets its
+// interfaces from the annotation at CNI ADD.
+type PodInterfaceAdd struct {
+	PodNamespace         string          `protobuf:"bytes,1,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	PodName              string          `protobuf:"bytes,2,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	Interface            []*PodInterface `protobuf:"bytes,3,rep,name=interface,proto3" json:"interface,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *PodInterfaceAdd) Reset()         { *m = PodInterfaceAdd{} }
+func (m *PodInterfaceAdd) String() string { return proto.CompactTextString(m) }
+func (*PodInterfaceAdd) ProtoMessage()    {}
+func (*PodInterfaceAdd) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{11}
+}
+
+func (m *PodInterfaceAdd) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PodInterfaceAdd.Unmarshal(m, b)
+}
+func (m *PodInterfaceAdd) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PodInterfaceAdd.Marshal(b, m, deterministic)
+}
+func (m *PodInterfaceAdd) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PodInterfaceAdd.Merge(m, src)
+}
+func (m *PodInterfaceAdd) XXX_Size() int {
+	return xxx_messageInfo_PodInterfaceAdd.Size(m)
+}
+func (m *PodInterfaceAdd) XXX_DiscardUnknown() {
+	xxx_messageInfo_PodInterfaceAdd.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PodInterfaceAdd proto.InternalMessageInfo
+
+func (m *PodInterfaceAdd) GetPodNamespace() string {
+	if m != nil {
+		return m.PodNamespace
+	}
+	return ""
+}
+
+func (m *PodInterfaceAdd) GetPodName() string {
+	if m != nil {
+		return m.PodName
+	}
+	return ""
+}
+
+func (m *PodInterfaceAdd) GetInterface() []*PodInterface {
+	if m != nil {
+		return m.Interface
+	}
+	return nil
+}
+
+type PodInterfaceRemove struct {
+	PodNamespace         string   `protobuf:"bytes,1,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	PodName              string   `protobuf:"bytes,2,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	Interface            []string `protobuf:"bytes,3,rep,name=interface,proto3" json:"interface,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PodInterfaceRemove) Reset()         { *m = PodInterfaceRemove{} }
+func (m *PodInterfaceRemove) String() string { return proto.CompactTextString(m) }
+func (*PodInterfaceRemove) ProtoMessage()    {}
+func (*PodInterfaceRemove) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{12}
+}
+
+func (m *PodInterfaceRemove) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PodInterfaceRemove.Unmarshal(m, b)
+}
+func (m *PodInterfaceRemove) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PodInterfaceRemove.Marshal(b, m, deterministic)
+}
+func (m *PodInterfaceRemove) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PodInterfaceRemove.Merge(m, src)
+}
+func (m *PodInterfaceRemove) XXX_Size() int {
+	return xxx_messageInfo_PodInterfaceRemove.Size(m)
+}
+func (m *PodInterfaceRemove) XXX_DiscardUnknown() {
+	xxx_messageInfo_PodInterfaceRemove.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PodInterfaceRemove proto.InternalMessageInfo
+
+func (m *PodInterfaceRemove) GetPodNamespace() string {
+	if m != nil {
+		return m.PodNamespace
+	}
+	return ""
+}
+
+func (m *PodInterfaceRemove) GetPodName() string {
+	if m != nil {
+		return m.PodName
+	}
+	return ""
+}
+
+func (m *PodInterfaceRemove) GetInterface() []string {
+	if m != nil {
+		return m.Interface
+	}
+	return nil
+}
+
 type InSync struct {
 	NodeIntfIpAddress    string   `protobuf:"bytes,1,opt,name=node_intf_ip_address,json=nodeIntfIpAddress,proto3" json:"node_intf_ip_address,omitempty"`
 	NodeIntfMacAddress   string   `protobuf:"bytes,2,opt,name=node_intf_mac_address,json=nodeIntfMacAddress,proto3" json:"node_intf_mac_address,omitempty"`
@@ -553,7 +889,7 @@ func (m *InSync) Reset()         { *m = InSync{} }
 func (m *InSync) String() string { return proto.CompactTextString(m) }
 func (*InSync) ProtoMessage()    {}
 func (*InSync) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ee04cc9cbb38bc3, []int{9}
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{13}
 }
 
 func (m *InSync) XXX_Unmarshal(b []byte) error {
@@ -588,17 +924,183 @@ func (m *InSync) GetNodeIntfMacAddress() string {
 	return ""
 }
 
+type StatusReport struct {
+	NodeName          string `protobuf:"bytes,1,opt,name=node_name,json=nodeName,proto3" json:"node_name,omitempty"`
+	AppliedGeneration uint64 `protobuf:"varint,2,opt,name=applied_generation,json=appliedGeneration,proto3" json:"applied_generation,omitempty"`
+	// Request a full ProviderNetworkSync on the subscribe stream
+	Resync bool `protobuf:"varint,3,opt,name=resync,proto3" json:"resync,omitempty"`
+	// Chain routes that could not be added since the last report
+	RouteFailures        []*RouteFailure `protobuf:"bytes,4,rep,name=route_failures,json=routeFailures,proto3" json:"route_failures,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *StatusReport) Reset()         { *m = StatusReport{} }
+func (m *StatusReport) String() string { return proto.CompactTextString(m) }
+func (*StatusReport) ProtoMessage()    {}
+func (*StatusReport) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{14}
+}
+
+func (m *StatusReport) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusReport.Unmarshal(m, b)
+}
+func (m *StatusReport) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusReport.Marshal(b, m, deterministic)
+}
+func (m *StatusReport) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusReport.Merge(m, src)
+}
+func (m *StatusReport) XXX_Size() int {
+	return xxx_messageInfo_StatusReport.Size(m)
+}
+func (m *StatusReport) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusReport.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusReport proto.InternalMessageInfo
+
+func (m *StatusReport) GetNodeName() string {
+	if m != nil {
+		return m.NodeName
+	}
+	return ""
+}
+
+func (m *StatusReport) GetAppliedGeneration() uint64 {
+	if m != nil {
+		return m.AppliedGeneration
+	}
+	return 0
+}
+
+func (m *StatusReport) GetResync() bool {
+	if m != nil {
+		return m.Resync
+	}
+	return false
+}
+
+func (m *StatusReport) GetRouteFailures() []*RouteFailure {
+	if m != nil {
+		return m.RouteFailures
+	}
+	return nil
+}
+
+type RouteFailure struct {
+	Chain                string   `protobuf:"bytes,1,opt,name=chain,proto3" json:"chain,omitempty"`
+	PodNamespace         string   `protobuf:"bytes,2,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	PodName              string   `protobuf:"bytes,3,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RouteFailure) Reset()         { *m = RouteFailure{} }
+func (m *RouteFailure) String() string { return proto.CompactTextString(m) }
+func (*RouteFailure) ProtoMessage()    {}
+func (*RouteFailure) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{15}
+}
+
+func (m *RouteFailure) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RouteFailure.Unmarshal(m, b)
+}
+func (m *RouteFailure) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RouteFailure.Marshal(b, m, deterministic)
+}
+func (m *RouteFailure) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RouteFailure.Merge(m, src)
+}
+func (m *RouteFailure) XXX_Size() int {
+	return xxx_messageInfo_RouteFailure.Size(m)
+}
+func (m *RouteFailure) XXX_DiscardUnknown() {
+	xxx_messageInfo_RouteFailure.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RouteFailure proto.InternalMessageInfo
+
+func (m *RouteFailure) GetChain() string {
+	if m != nil {
+		return m.Chain
+	}
+	return ""
+}
+
+func (m *RouteFailure) GetPodNamespace() string {
+	if m != nil {
+		return m.PodNamespace
+	}
+	return ""
+}
+
+func (m *RouteFailure) GetPodName() string {
+	if m != nil {
+		return m.PodName
+	}
+	return ""
+}
+
+type StatusReportAck struct {
+	Generation           uint64   `protobuf:"varint,1,opt,name=generation,proto3" json:"generation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatusReportAck) Reset()         { *m = StatusReportAck{} }
+func (m *StatusReportAck) String() string { return proto.CompactTextString(m) }
+func (*StatusReportAck) ProtoMessage()    {}
+func (*StatusReportAck) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ee04cc9cbb38bc3, []int{16}
+}
+
+func (m *StatusReportAck) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusReportAck.Unmarshal(m, b)
+}
+func (m *StatusReportAck) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusReportAck.Marshal(b, m, deterministic)
+}
+func (m *StatusReportAck) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusReportAck.Merge(m, src)
+}
+func (m *StatusReportAck) XXX_Size() int {
+	return xxx_messageInfo_StatusReportAck.Size(m)
+}
+func (m *StatusReportAck) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusReportAck.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusReportAck proto.InternalMessageInfo
+
+func (m *StatusReportAck) GetGeneration() uint64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*SubscribeContext)(nil), "SubscribeContext")
 	proto.RegisterType((*Notification)(nil), "Notification")
 	proto.RegisterType((*ProviderNetworkCreate)(nil), "ProviderNetworkCreate")
 	proto.RegisterType((*ProviderNetworkRemove)(nil), "ProviderNetworkRemove")
+	proto.RegisterType((*ProviderNetworkSync)(nil), "ProviderNetworkSync")
 	proto.RegisterType((*VlanInfo)(nil), "VlanInfo")
 	proto.RegisterType((*DirectInfo)(nil), "DirectInfo")
 	proto.RegisterType((*RouteData)(nil), "RouteData")
 	proto.RegisterType((*ContainerRouteInsert)(nil), "ContainerRouteInsert")
 	proto.RegisterType((*ContainerRouteRemove)(nil), "ContainerRouteRemove")
+	proto.RegisterType((*PodInterface)(nil), "PodInterface")
+	proto.RegisterType((*PodInterfaceAdd)(nil), "PodInterfaceAdd")
+	proto.RegisterType((*PodInterfaceRemove)(nil), "PodInterfaceRemove")
 	proto.RegisterType((*InSync)(nil), "InSync")
+	proto.RegisterType((*StatusReport)(nil), "StatusReport")
+	proto.RegisterType((*RouteFailure)(nil), "RouteFailure")
+	proto.RegisterType((*StatusReportAck)(nil), "StatusReportAck")
 }
 
 func init() {
@@ -606,45 +1108,70 @@ func init() {
 }
 
 var fileDescriptor_5ee04cc9cbb38bc3 = []byte{
-	// 601 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0x4f, 0x6f, 0xd3, 0x4e,
-	0x10, 0x6d, 0x92, 0x36, 0xa9, 0x27, 0xfd, 0x93, 0xae, 0xd2, 0xdf, 0x2f, 0x80, 0x90, 0x82, 0x7b,
-	0x89, 0x90, 0x70, 0xda, 0x72, 0xe5, 0x02, 0xad, 0x50, 0x2d, 0x41, 0x84, 0x5c, 0xc4, 0x85, 0xc3,
-	0x6a, 0xbb, 0xde, 0x54, 0xab, 0x3a, 0xbb, 0xd6, 0x66, 0x9b, 0xe0, 0x0f, 0xc0, 0xe7, 0xe0, 0x9b,
-	0x22, 0xb4, 0x7f, 0x6c, 0x27, 0x4d, 0x0e, 0x1c, 0xb8, 0xed, 0xbe, 0x37, 0xf3, 0xe6, 0xed, 0x8c,
-	0x3d, 0x70, 0x9c, 0x2b, 0xa9, 0xe5, 0x58, 0x4c, 0x45, 0x64, 0x4f, 0xe1, 0x18, 0x7a, 0xb7, 0x8f,
-	0x77, 0x73, 0xaa, 0xf8, 0x1d, 0xbb, 0x92, 0x42, 0xb3, 0x1f, 0x1a, 0xbd, 0x80, 0x40, 0xc8, 0x94,
-	0x61, 0x41, 0x66, 0x6c, 0xd0, 0x18, 0x36, 0x46, 0x41, 0xb2, 0x6f, 0x80, 0x09, 0x99, 0xb1, 0xf0,
-	0x77, 0x13, 0x0e, 0x26, 0x52, 0xf3, 0x29, 0xa7, 0x44, 0x73, 0x29, 0xd0, 0x33, 0xd8, 0xa7, 0x82,
-	0x63, 0x5d, 0xe4, 0x65, 0x70, 0x87, 0x0a, 0xfe, 0xb5, 0xc8, 0x19, 0x0a, 0xa1, 0xc3, 0x05, 0x9e,
-	0x17, 0x82, 0x0e, 0x9a, 0xc3, 0xc6, 0xa8, 0x7b, 0xd9, 0x89, 0x62, 0x71, 0x5b, 0x08, 0x7a, 0xb3,
-	0x93, 0xb4, 0xb9, 0x3d, 0xa1, 0x8f, 0x80, 0x72, 0x25, 0x17, 0x3c, 0x65, 0x0a, 0x8b, 0x25, 0xa6,
-	0x8a, 0x11, 0xcd, 0x06, 0x2d, 0x1b, 0xfe, 0x5f, 0xf4, 0xc5, 0x53, 0x13, 0xa6, 0x97, 0x52, 0x3d,
-	0x5c, 0x59, 0xf6, 0x66, 0x27, 0xe9, 0x95, 0x39, 0x93, 0xa5, 0xc3, 0x9e, 0xea, 0x28, 0x36, 0x93,
-	0x0b, 0x36, 0xd8, 0xdd, 0xae, 0x93, 0x58, 0x76, 0x5d, 0xc7, 0x61, 0x28, 0x86, 0x3e, 0x95, 0x42,
-	0x13, 0x2e, 0x34, 0x53, 0x58, 0x69, 0xcc, 0xc5, 0x9c, 0x29, 0x3d, 0xd8, 0xb3, 0x4a, 0xa7, 0xd1,
-	0x95, 0x23, 0x99, 0x4a, 0xe4, 0xa3, 0x66, 0xb1, 0x25, 0x6f, 0x76, 0x12, 0x54, 0x27, 0x25, 0xda,
-	0xa1, 0x9b, 0x52, 0xde, 0x54, 0x7b, 0xab, 0x54, 0xe5, 0x69, 0x4d, 0xca, 0xa1, 0x1f, 0x02, 0xe8,
-	0xe4, 0xa4, 0xc8, 0x24, 0x49, 0xc3, 0x9f, 0x0d, 0x38, 0xdd, 0xda, 0x16, 0x34, 0x82, 0xde, 0x6a,
-	0x0b, 0x56, 0xc6, 0x77, 0x54, 0x3f, 0xd3, 0x0c, 0x11, 0xbd, 0x84, 0xdd, 0x45, 0x46, 0x84, 0x9f,
-	0x4a, 0x10, 0x7d, 0xcb, 0x88, 0x88, 0xc5, 0x54, 0x26, 0x16, 0x46, 0x67, 0xd0, 0x4e, 0xb9, 0x62,
-	0x54, 0xfb, 0x39, 0x74, 0xa3, 0x6b, 0x7b, 0xb5, 0x21, 0x9e, 0x0a, 0x7f, 0x6d, 0xfa, 0xf0, 0x2d,
-	0xfc, 0x7b, 0x1f, 0xaf, 0xe1, 0xc4, 0x14, 0xc4, 0x99, 0xbc, 0xe7, 0x94, 0x64, 0x98, 0x0b, 0x3d,
-	0xb5, 0xa6, 0x82, 0xe4, 0xd8, 0x10, 0x9f, 0x1c, 0x1e, 0x0b, 0x3d, 0x45, 0xe7, 0xd0, 0x77, 0x95,
-	0x71, 0x25, 0x6e, 0xc3, 0x5b, 0x36, 0x1c, 0x39, 0xae, 0x34, 0x64, 0x32, 0xc2, 0x07, 0xd8, 0x2f,
-	0x1f, 0x86, 0xfe, 0x87, 0x8e, 0xad, 0xc4, 0x53, 0x6f, 0xa5, 0x6d, 0xae, 0x71, 0x8a, 0xce, 0xe0,
-	0x70, 0x5d, 0xcf, 0x95, 0x3f, 0xc8, 0x57, 0x94, 0xd0, 0x2b, 0x38, 0x58, 0xb3, 0xe8, 0x6a, 0x76,
-	0xb3, 0xda, 0x5e, 0x78, 0x01, 0x50, 0x37, 0x69, 0x53, 0xb5, 0xb1, 0xa9, 0x1a, 0xbe, 0x81, 0xc0,
-	0x4e, 0xfe, 0x9a, 0x68, 0x82, 0x7a, 0xd0, 0x4a, 0xe7, 0xda, 0x57, 0x37, 0x47, 0x74, 0x04, 0xcd,
-	0xfb, 0xa5, 0x2f, 0xd5, 0xbc, 0x5f, 0x86, 0xdf, 0xa1, 0xbf, 0xed, 0xe3, 0x33, 0xe6, 0x68, 0x89,
-	0xd7, 0xef, 0xeb, 0x56, 0x58, 0x9c, 0xa2, 0x21, 0xec, 0x29, 0x93, 0x31, 0x68, 0x0e, 0x5b, 0xa3,
-	0xee, 0x25, 0x44, 0x55, 0xdd, 0xc4, 0x11, 0x9b, 0xe2, 0x7e, 0x96, 0xff, 0x44, 0x3c, 0x83, 0xb6,
-	0xfb, 0xef, 0xd1, 0x18, 0xfa, 0x76, 0xb5, 0x98, 0x9e, 0x60, 0x9e, 0x63, 0x92, 0xa6, 0x8a, 0xcd,
-	0xe7, 0x5e, 0xf6, 0xc4, 0x70, 0xa6, 0x35, 0x71, 0xfe, 0xde, 0x11, 0xe8, 0x02, 0x4e, 0xeb, 0x84,
-	0x19, 0xa1, 0x55, 0x86, 0x6b, 0x14, 0x2a, 0x33, 0x3e, 0x13, 0xea, 0x53, 0x2e, 0xdf, 0x41, 0x20,
-	0xa6, 0xc2, 0xee, 0xa8, 0x02, 0x8d, 0x21, 0xa8, 0xf6, 0x1b, 0x3a, 0x89, 0x9e, 0xee, 0xba, 0xe7,
-	0x87, 0xd1, 0xea, 0x32, 0x3b, 0x6f, 0xdc, 0xb5, 0xed, 0x5e, 0x7c, 0xfb, 0x27, 0x00, 0x00, 0xff,
-	0xff, 0x47, 0xfb, 0x68, 0x42, 0x2a, 0x05, 0x00, 0x00,
+	// 993 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x56, 0xcd, 0x6e, 0x23, 0x45,
+	0x10, 0xce, 0xd8, 0x89, 0x9d, 0x29, 0xdb, 0xb1, 0xd3, 0xeb, 0x2c, 0xc3, 0xb2, 0x01, 0x33, 0x7b,
+	0x89, 0x40, 0x3b, 0xde, 0x2c, 0x2b, 0x71, 0x42, 0x22, 0x64, 0xb5, 0xc4, 0x12, 0x58, 0xab, 0x09,
+	0xe2, 0x3a, 0xea, 0xcc, 0xb4, 0x4d, 0x2b, 0xe3, 0x9e, 0x51, 0x4f, 0x3b, 0xc6, 0x17, 0x6e, 0x3c,
+	0x06, 0xe2, 0xca, 0x95, 0x3b, 0xaf, 0xc1, 0xfb, 0xa0, 0xa9, 0xee, 0xf9, 0xf1, 0x0f, 0x2c, 0x12,
+	0x17, 0x6e, 0xdd, 0xf5, 0x75, 0x55, 0x7d, 0xf5, 0xd3, 0xd5, 0x0d, 0xfd, 0x54, 0x26, 0x2a, 0x19,
+	0x8b, 0x99, 0xf0, 0x70, 0xe5, 0x8e, 0x61, 0x70, 0xbb, 0xbc, 0xcb, 0x42, 0xc9, 0xef, 0xd8, 0x75,
+	0x22, 0x14, 0xfb, 0x51, 0x91, 0x0f, 0xc0, 0x16, 0x49, 0xc4, 0x02, 0x41, 0x17, 0xcc, 0xb1, 0x46,
+	0xd6, 0x85, 0xed, 0x1f, 0xe7, 0x82, 0x29, 0x5d, 0x30, 0xf7, 0xcf, 0x43, 0xe8, 0x4e, 0x13, 0xc5,
+	0x67, 0x3c, 0xa4, 0x8a, 0x27, 0x82, 0xbc, 0x0f, 0xc7, 0xa1, 0xe0, 0x81, 0x5a, 0xa7, 0xc5, 0xe1,
+	0x76, 0x28, 0xf8, 0x77, 0xeb, 0x94, 0x11, 0x17, 0xda, 0x5c, 0x04, 0xd9, 0x5a, 0x84, 0x4e, 0x63,
+	0x64, 0x5d, 0x74, 0x5e, 0xb6, 0xbd, 0x89, 0xb8, 0x5d, 0x8b, 0xf0, 0xe6, 0xc0, 0x6f, 0x71, 0x5c,
+	0x91, 0x37, 0x40, 0x52, 0x99, 0x3c, 0xf0, 0x88, 0xc9, 0x40, 0xac, 0x82, 0x50, 0x32, 0xaa, 0x98,
+	0xd3, 0xc4, 0xe3, 0x8f, 0xbd, 0xb7, 0x06, 0x9a, 0x32, 0xb5, 0x4a, 0xe4, 0xfd, 0x35, 0xa2, 0x37,
+	0x07, 0xfe, 0xa0, 0xd0, 0x99, 0xae, 0xb4, 0x6c, 0xdb, 0x8e, 0x64, 0x8b, 0xe4, 0x81, 0x39, 0x87,
+	0xfb, 0xed, 0xf8, 0x88, 0x6e, 0xda, 0xd1, 0x32, 0x32, 0x81, 0x61, 0x98, 0x08, 0x45, 0xb9, 0x50,
+	0x4c, 0x06, 0x52, 0x05, 0x5c, 0x64, 0x4c, 0x2a, 0xe7, 0x08, 0x2d, 0x9d, 0x79, 0xd7, 0x1a, 0x64,
+	0xd2, 0x4f, 0x96, 0x8a, 0x4d, 0x10, 0xbc, 0x39, 0xf0, 0x49, 0xa5, 0xe4, 0x2b, 0x2d, 0xdd, 0x35,
+	0x65, 0x48, 0xb5, 0xf6, 0x9a, 0x2a, 0x39, 0x6d, 0x98, 0x32, 0xac, 0xbe, 0x84, 0x41, 0x3d, 0x3a,
+	0x4c, 0x69, 0x1b, 0xcd, 0x0c, 0xb7, 0x63, 0x33, 0xf9, 0x3d, 0xa9, 0x22, 0xc3, 0x3c, 0xbf, 0x82,
+	0x6e, 0x9a, 0x44, 0x01, 0x17, 0x6a, 0x16, 0xd0, 0x28, 0x72, 0x6c, 0xd4, 0x1e, 0x78, 0x6f, 0x93,
+	0x68, 0x92, 0xbb, 0x9a, 0xd1, 0x90, 0x5d, 0x45, 0xd1, 0xcd, 0x81, 0x0f, 0x29, 0x8a, 0x66, 0x57,
+	0x51, 0x44, 0xbe, 0x80, 0x7e, 0xa9, 0x65, 0xd8, 0x03, 0x2a, 0x3e, 0xda, 0x50, 0x2c, 0xb9, 0xf7,
+	0x8c, 0xae, 0xa1, 0xfd, 0x21, 0xc0, 0x9c, 0x09, 0x26, 0xb1, 0x53, 0x9c, 0xe3, 0x91, 0x75, 0x71,
+	0xe8, 0xd7, 0x24, 0x5f, 0xd9, 0xd0, 0x4e, 0xe9, 0x3a, 0x4e, 0x68, 0xe4, 0xfe, 0x6c, 0xc1, 0xd9,
+	0xde, 0x6a, 0x93, 0x8b, 0xcd, 0xd8, 0x6b, 0x5d, 0x59, 0x8b, 0x31, 0xef, 0x4d, 0x72, 0x0e, 0x87,
+	0x0f, 0x31, 0x15, 0xa6, 0xd9, 0x6c, 0xef, 0xfb, 0x98, 0x8a, 0x89, 0x98, 0x25, 0x3e, 0x8a, 0xc9,
+	0x33, 0x68, 0x45, 0x5c, 0xb2, 0x50, 0x99, 0xf6, 0xea, 0x78, 0xaf, 0x71, 0x8b, 0x47, 0x0c, 0xe4,
+	0xfe, 0xba, 0xcb, 0xc3, 0x04, 0xf3, 0xef, 0x79, 0x7c, 0x02, 0xa7, 0xb9, 0xc3, 0x20, 0x4e, 0xe6,
+	0x3c, 0xa4, 0x31, 0xa6, 0x0f, 0x49, 0xd9, 0x7e, 0x3f, 0x07, 0xbe, 0xd1, 0xf2, 0x3c, 0x51, 0xe4,
+	0x05, 0x0c, 0xb5, 0xe7, 0xa0, 0x34, 0x8e, 0xc7, 0x9b, 0x78, 0x9c, 0x68, 0xac, 0x20, 0x94, 0x6b,
+	0xb8, 0x53, 0x78, 0xb4, 0xa7, 0xe4, 0xe4, 0x73, 0xe8, 0xd4, 0xe8, 0x39, 0xd6, 0xa8, 0xf9, 0xf7,
+	0x37, 0xc8, 0x87, 0x8a, 0xb1, 0x7b, 0x0f, 0xc7, 0x45, 0xa2, 0xc8, 0x7b, 0xd0, 0x46, 0xe6, 0x3c,
+	0x32, 0xa1, 0xb5, 0xf2, 0xed, 0x24, 0x22, 0xcf, 0xa0, 0xb7, 0xc9, 0x4f, 0x87, 0xd3, 0x4d, 0x6b,
+	0xcc, 0xc8, 0xc7, 0xd0, 0xdd, 0x08, 0x59, 0xc7, 0xd0, 0x89, 0xab, 0x70, 0xdd, 0x4b, 0x80, 0x2a,
+	0xe9, 0xbb, 0x56, 0xad, 0x5d, 0xab, 0xee, 0x73, 0xb0, 0xf1, 0x82, 0xbc, 0xa6, 0x8a, 0x92, 0x01,
+	0x34, 0xa3, 0x4c, 0x19, 0xef, 0xf9, 0x92, 0x9c, 0x40, 0x63, 0xbe, 0x32, 0xae, 0x1a, 0xf3, 0x95,
+	0xfb, 0xbb, 0x05, 0xc3, 0x7d, 0x97, 0x34, 0x67, 0x17, 0x16, 0xf2, 0x2a, 0xc0, 0x4e, 0x29, 0x9b,
+	0x44, 0x64, 0x04, 0x47, 0x32, 0xd7, 0x70, 0x1a, 0x98, 0x3d, 0xf0, 0x4a, 0xc7, 0xbe, 0x06, 0x90,
+	0x71, 0x12, 0x61, 0xf1, 0xb3, 0x94, 0x86, 0xcc, 0x38, 0xce, 0xef, 0xd6, 0xb4, 0x90, 0xe5, 0x23,
+	0xb1, 0x38, 0x84, 0x13, 0xc8, 0xf6, 0xdb, 0x06, 0x27, 0x43, 0x38, 0x0a, 0x7f, 0xa0, 0x5c, 0xe0,
+	0x3c, 0xb1, 0x7d, 0xbd, 0x71, 0xff, 0xd8, 0xe1, 0x6c, 0x7a, 0xee, 0x7f, 0xc1, 0xf9, 0x23, 0xe8,
+	0xc4, 0x8c, 0x3e, 0xb0, 0xa0, 0x62, 0x7e, 0xec, 0x03, 0x8a, 0xae, 0x91, 0xfe, 0x2f, 0x16, 0x74,
+	0xeb, 0xe3, 0x80, 0x3c, 0x05, 0x9b, 0x17, 0x1b, 0xc3, 0xb9, 0x12, 0x90, 0x73, 0x00, 0x9e, 0xe6,
+	0x43, 0x48, 0xb2, 0x2c, 0x33, 0xa5, 0xb4, 0x79, 0x7a, 0xa5, 0x05, 0xb9, 0xbb, 0x05, 0x0d, 0x4b,
+	0x5c, 0x93, 0x85, 0x05, 0x0d, 0x8b, 0x03, 0xe7, 0x00, 0x73, 0xaa, 0xd8, 0x8a, 0xae, 0x03, 0x9e,
+	0x1a, 0xb2, 0xb6, 0x91, 0x4c, 0xd2, 0xbc, 0x45, 0x16, 0x6a, 0x89, 0x34, 0x8f, 0xfc, 0x7c, 0xe9,
+	0xfe, 0x04, 0xfd, 0xad, 0x31, 0xb7, 0x9b, 0x13, 0xeb, 0x1d, 0x39, 0x69, 0x6c, 0xe6, 0xe4, 0xd3,
+	0x7a, 0x84, 0x4d, 0xcc, 0x7c, 0x6f, 0x73, 0x24, 0x56, 0xb8, 0x2b, 0x81, 0xec, 0x4e, 0xcb, 0xff,
+	0x4c, 0xe1, 0xe9, 0x36, 0x85, 0x7a, 0x92, 0xdd, 0x18, 0x5a, 0xfa, 0xad, 0x25, 0x63, 0x18, 0xe2,
+	0x73, 0x8e, 0x43, 0xbc, 0x96, 0x78, 0xed, 0xee, 0x34, 0xc7, 0xf2, 0x7b, 0x36, 0x29, 0x0b, 0x70,
+	0x09, 0x67, 0x95, 0x42, 0xbd, 0x14, 0x9a, 0x00, 0x29, 0x34, 0xbe, 0x2d, 0x4b, 0xe2, 0xfe, 0x66,
+	0x41, 0xf7, 0x56, 0x51, 0xb5, 0xcc, 0x7c, 0x96, 0x26, 0xf2, 0x9f, 0xff, 0x10, 0xe4, 0x39, 0x10,
+	0x9a, 0xa6, 0x31, 0x67, 0x51, 0x50, 0x7b, 0x1e, 0x1a, 0xf8, 0x3c, 0x9c, 0x1a, 0xe4, 0xeb, 0x12,
+	0x20, 0x8f, 0xa1, 0x25, 0x19, 0x3e, 0x79, 0x4d, 0x6c, 0x3d, 0xb3, 0x23, 0xaf, 0xe0, 0x04, 0x1b,
+	0x3c, 0x98, 0x51, 0x1e, 0x2f, 0x25, 0xcb, 0x9c, 0x43, 0x53, 0x08, 0xbc, 0x02, 0x6f, 0xb4, 0xd4,
+	0xef, 0xc9, 0xda, 0x2e, 0x73, 0x23, 0xe8, 0xd6, 0xe1, 0xea, 0x46, 0x5a, 0xb5, 0x1b, 0xb9, 0x5b,
+	0x9c, 0xc6, 0x3b, 0x8a, 0xd3, 0xdc, 0x28, 0x8e, 0x7b, 0x09, 0xfd, 0x7a, 0x3e, 0xae, 0xc2, 0xfb,
+	0xad, 0xc7, 0xd0, 0xda, 0x7e, 0x0c, 0x5f, 0x2e, 0xc0, 0x16, 0x33, 0x81, 0x7f, 0xab, 0x35, 0x19,
+	0x83, 0x5d, 0xfe, 0xcb, 0xc8, 0xa9, 0xb7, 0xfd, 0x47, 0x7b, 0xd2, 0xf3, 0xea, 0x9f, 0xb0, 0x17,
+	0x16, 0x19, 0x43, 0x57, 0xbb, 0xd2, 0x6e, 0x49, 0xcf, 0xab, 0xfb, 0x7f, 0x32, 0xf0, 0xb6, 0xe8,
+	0xdc, 0xb5, 0xf0, 0x03, 0xf8, 0xd9, 0x5f, 0x03, 0x00, 0x47, 0xa5, 0x89, 0x65, 0x13, 0x0a, 0x00,
+	0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -660,6 +1187,7 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type NfnNotifyClient interface {
 	Subscribe(ctx context.Context, in *SubscribeContext, opts ...grpc.CallOption) (NfnNotify_SubscribeClient, error)
+	ReportStatus(ctx context.Context, in *StatusReport, opts ...grpc.CallOption) (*StatusReportAck, error)
 }
 
 type nfnNotifyClient struct {
@@ -702,9 +1230,19 @@ func (x *nfnNotifySubscribeClient) Recv() (*Notification, error) {
 	return m, nil
 }
 
+func (c *nfnNotifyClient) ReportStatus(ctx context.Context, in *StatusReport, opts ...grpc.CallOption) (*StatusReportAck, error) {
+	out := new(StatusReportAck)
+	err := c.cc.Invoke(ctx, "/nfnNotify/ReportStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NfnNotifyServer is the server API for NfnNotify service.
 type NfnNotifyServer interface {
 	Subscribe(*SubscribeContext, NfnNotify_SubscribeServer) error
+	ReportStatus(context.Context, *StatusReport) (*StatusReportAck, error)
 }
 
 // UnimplementedNfnNotifyServer can be embedded to have forward compatible implementations.
@@ -714,6 +1252,9 @@ type UnimplementedNfnNotifyServer struct {
 func (*UnimplementedNfnNotifyServer) Subscribe(req *SubscribeContext, srv NfnNotify_SubscribeServer) error {
 	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
 }
+func (*UnimplementedNfnNotifyServer) ReportStatus(ctx context.Context, req *StatusReport) (*StatusReportAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportStatus not implemented")
+}
 
 func RegisterNfnNotifyServer(s *grpc.Server, srv NfnNotifyServer) {
 	s.RegisterService(&_NfnNotify_serviceDesc, srv)
@@ -740,10 +1281,33 @@ func (x *nfnNotifySubscribeServer) Send(m *Notification) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _NfnNotify_ReportStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusReport)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NfnNotifyServer).ReportStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/nfnNotify/ReportStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NfnNotifyServer).ReportStatus(ctx, req.(*StatusReport))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _NfnNotify_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "nfnNotify",
 	HandlerType: (*NfnNotifyServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReportStatus",
+			Handler:    _NfnNotify_ReportStatus_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Subscribe",
@@ -17,17 +17,26 @@
 package nfn
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"ovn4nfv-k8s-plugin/internal/pkg/metrics"
+	"ovn4nfv-k8s-plugin/internal/pkg/netattachdef"
 	pb "ovn4nfv-k8s-plugin/internal/pkg/nfnNotify/proto"
 	chaining "ovn4nfv-k8s-plugin/internal/pkg/utils"
 	"ovn4nfv-k8s-plugin/internal/pkg/node"
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
 	v1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
 	clientset "ovn4nfv-k8s-plugin/pkg/generated/clientset/versioned"
 	"strings"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -39,21 +48,38 @@ var log = logf.Log.WithName("rpc-server")
 type client struct {
 	context *pb.SubscribeContext
 	stream  pb.NfnNotify_SubscribeServer
+	// mu serializes sends on the stream and guards the generations
+	mu         sync.Mutex
+	generation uint64 // Last generation sent to the node
+	applied    uint64 // Last generation the node reported as applied
 }
 
 type serverDB struct {
 	name       string
-	clientList map[string]client
+	mu         sync.Mutex
+	clientList map[string]*client
 }
 
-var notifServer *serverDB
+// notifServer is created up front as controllers may send notifications
+// before the gRPC server is started
+var notifServer = newServer()
 var stopChan chan interface{}
 
 var pnClientset *clientset.Clientset
+
+// routeFailureHandler is called when an agent failed to add the routes of the
+// chain to the pod
+var routeFailureHandler func(chain, podNamespace, podName string)
+
+// SetRouteFailureHandler sets the handler of the chain routes the agents
+// failed to add
+func SetRouteFailureHandler(h func(chain, podNamespace, podName string)) {
+	routeFailureHandler = h
+}
 var kubeClientset *kubernetes.Clientset
 
 func newServer() *serverDB {
-	return &serverDB{name: "nfnNotifServer", clientList: make(map[string]client)}
+	return &serverDB{name: "nfnNotifServer", clientList: make(map[string]*client)}
 }
 
 // Subscribe stores the client information & sends data
@@ -68,18 +94,13 @@ func (s *serverDB) Subscribe(sc *pb.SubscribeContext, ss pb.NfnNotify_SubscribeS
 	if err != nil {
 		return fmt.Errorf("Error in creating node logical port for node- %s: %v", nodeName, err)
 	}
-	cp := client{
-		context: sc,
-		stream:  ss,
-	}
-	s.clientList[nodeName] = cp
+	cp := s.addClient(nodeName, sc, ss)
+	defer s.removeClient(nodeName, cp)
 
-	providerNetworklist, err := pnClientset.K8sV1alpha1().ProviderNetworks("default").List(v1.ListOptions{})
-	if err == nil {
-		for _, pn := range providerNetworklist.Items {
-			log.Info("Send message", "Provider Network", pn.GetName())
-			SendNotif(&pn, "create", nodeName)
-		}
+	log.Info("Send ProviderNetworkSync", "node name", nodeName)
+	if err = sendSync(cp, nodeName); err != nil {
+		log.Error(err, "Unable to send provider network sync", "node name", nodeName)
+		return err
 	}
 	inSyncMsg := pb.Notification{
 		CniType: "ovn4nfv",
@@ -91,38 +112,244 @@ func (s *serverDB) Subscribe(sc *pb.SubscribeContext, ss pb.NfnNotify_SubscribeS
 		},
 	}
 	log.Info("Send Insync")
-	if err = cp.stream.Send(&inSyncMsg); err != nil {
+	if err = cp.send(&inSyncMsg); err != nil {
 		log.Error(err, "Unable to send sync", "node name", nodeName)
 	}
 	log.Info("Subscribe Completed")
-	// Keep stream open
-	for {
-		select {
-		case <-stopChan:
+	// Keep stream open till the node goes away
+	select {
+	case <-ss.Context().Done():
+		log.Info("Subscribe stream closed", "node name", nodeName)
+	case <-stopChan:
+	}
+	return nil
+}
+
+// ReportStatus records the generation applied by the node and sends a
+// full ProviderNetworkSync if the node asks for a resync
+func (s *serverDB) ReportStatus(ctx context.Context, sr *pb.StatusReport) (*pb.StatusReportAck, error) {
+	nodeName := sr.GetNodeName()
+	cp := s.GetClient(nodeName)
+	if cp == nil {
+		return nil, fmt.Errorf("Node %s is not subscribed", nodeName)
+	}
+	generation := cp.setApplied(sr.GetAppliedGeneration())
+	log.V(1).Info("Status report", "node name", nodeName, "applied", sr.GetAppliedGeneration(), "generation", generation)
+	for _, f := range sr.GetRouteFailures() {
+		log.Info("Chain routes not added", "node name", nodeName, "chain", f.GetChain(),
+			"namespace", f.GetPodNamespace(), "pod", f.GetPodName())
+		if routeFailureHandler != nil {
+			routeFailureHandler(f.GetChain(), f.GetPodNamespace(), f.GetPodName())
 		}
 	}
+	if sr.GetResync() {
+		log.Info("Resync requested", "node name", nodeName, "applied", sr.GetAppliedGeneration())
+		if err := sendSync(cp, nodeName); err != nil {
+			log.Error(err, "Unable to send provider network sync", "node name", nodeName)
+			return nil, err
+		}
+		generation = cp.getGeneration()
+	}
+	return &pb.StatusReportAck{Generation: generation}, nil
+}
+
+func (s *serverDB) addClient(nodeName string, sc *pb.SubscribeContext, ss pb.NfnNotify_SubscribeServer) *client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := &client{
+		context: sc,
+		stream:  ss,
+	}
+	// Generations keep increasing across reconnects of the same node
+	if old, ok := s.clientList[nodeName]; ok {
+		cp.generation = old.getGeneration()
+	}
+	s.clientList[nodeName] = cp
+	metrics.ConnectedAgents.Set(float64(len(s.clientList)))
+	return cp
 }
 
-func (s *serverDB) GetClient(nodeName string) client {
-	if val, ok := s.clientList[nodeName]; ok {
-		return val
+func (s *serverDB) removeClient(nodeName string, cp *client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Node may have subscribed again on a new stream
+	if s.clientList[nodeName] == cp {
+		delete(s.clientList, nodeName)
 	}
-	return client{}
+	metrics.ConnectedAgents.Set(float64(len(s.clientList)))
 }
 
-func updatePnStatus(pn *v1alpha1.ProviderNetwork, status string) error {
-	pnCopy := pn.DeepCopy()
-	pnCopy.Status.State = status
-	_, err := pnClientset.K8sV1alpha1().ProviderNetworks(pn.Namespace).Update(pnCopy)
+// GetClient returns the subscribed client for the node or nil
+func (s *serverDB) GetClient(nodeName string) *client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clientList[nodeName]
+}
+
+// getClients returns a copy of the subscribed clients
+func (s *serverDB) getClients() map[string]*client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clients := make(map[string]*client, len(s.clientList))
+	for name, cp := range s.clientList {
+		clients[name] = cp
+	}
+	return clients
+}
+
+// send stamps the message with the next generation of the node and sends it
+func (c *client) send(msg *pb.Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sendLocked(msg)
+}
+
+func (c *client) sendLocked(msg *pb.Notification) error {
+	c.generation++
+	msg.Generation = c.generation
+	err := c.stream.Send(msg)
+	if err != nil {
+		metrics.NotifySendFailures.WithLabelValues(messageType(msg)).Inc()
+	}
 	return err
 }
 
-func createVlanMsg(pn *v1alpha1.ProviderNetwork) pb.Notification {
+// messageType returns the payload type of the message, such as InSync
+func messageType(msg *pb.Notification) string {
+	t := fmt.Sprintf("%T", msg.Payload)
+	return t[strings.LastIndex(t, "_")+1:]
+}
+
+func (c *client) getGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *client) getApplied() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.applied
+}
+
+func (c *client) setApplied(applied uint64) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.applied = applied
+	return c.generation
+}
+
+// sendSync sends the full set of provider networks for the node. The client
+// lock is held while listing so that no delta can overtake the sync.
+func sendSync(cp *client, nodeName string) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	pnSync, keys, err := providerNetworkSync(nodeName)
+	if err != nil {
+		return err
+	}
+	msg := pb.Notification{
+		CniType: "ovn4nfv",
+		Payload: &pb.Notification_ProviderNwSync{
+			ProviderNwSync: pnSync,
+		},
+	}
+	if err := cp.sendLocked(&msg); err != nil {
+		return err
+	}
+	recordSynced(keys, nodeName, cp.generation)
+	return nil
+}
+
+// providerNetworkSync returns the provider networks to be configured on the
+// node, along with their keys
+func providerNetworkSync(nodeName string) (*pb.ProviderNetworkSync, []string, error) {
+	providerNetworklist, err := pnClientset.K8sV1alpha1().ProviderNetworks("").List(v1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	var keys []string
+	pnSync := &pb.ProviderNetworkSync{}
+	for i := range providerNetworklist.Items {
+		pn := &providerNetworklist.Items[i]
+		if !pn.DeletionTimestamp.IsZero() || pn.Spec.CniType != "ovn4nfv" {
+			continue
+		}
+		ok, err := pnOnNode(pn, nodeName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			continue
+		}
+		lsName, err := ovn.ProviderNetworkSwitch(pn.Namespace, pn.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		var msg pb.Notification
+		switch pn.Spec.ProviderNetType {
+		case "VLAN":
+			msg = createVlanMsg(pn, lsName)
+		case "DIRECT":
+			msg = createDirectMsg(pn, lsName)
+		default:
+			continue
+		}
+		log.V(1).Info("Add to sync", "Provider Network", pn.GetName(), "node name", nodeName)
+		pnSync.ProviderNw = append(pnSync.ProviderNw, msg.GetProviderNwCreate())
+		keys = append(keys, pnKey(pn))
+	}
+	return pnSync, keys, nil
+}
+
+// pnOnNode returns true if the provider network is configured on the node
+func pnOnNode(pn *v1alpha1.ProviderNetwork, nodeName string) (bool, error) {
+	var selector string
+	var labelList []string
+	switch pn.Spec.ProviderNetType {
+	case "VLAN":
+		selector = pn.Spec.Vlan.VlanNodeSelector
+		labelList = pn.Spec.Vlan.NodeLabelList
+	case "DIRECT":
+		selector = pn.Spec.Direct.DirectNodeSelector
+		labelList = pn.Spec.Direct.NodeLabelList
+	default:
+		return false, nil
+	}
+	switch {
+	case strings.EqualFold(selector, "ALL"):
+		return true, nil
+	case strings.EqualFold(selector, "ANY"):
+		return pn.Status.Node == nodeName, nil
+	case strings.EqualFold(selector, "SPECIFIC"):
+		lo := v1.ListOptions{
+			LabelSelector: strings.Join(labelList, ","),
+			FieldSelector: "metadata.name=" + nodeName,
+		}
+		nodes, err := kubeClientset.CoreV1().Nodes().List(lo)
+		if err != nil {
+			return false, err
+		}
+		return len(nodes.Items) > 0, nil
+	}
+	return false, nil
+}
+
+// vlanLogicalIntf returns the VLAN interface name of the provider network
+// of the logical switch lsName
+func vlanLogicalIntf(pn *v1alpha1.ProviderNetwork, lsName string) string {
+	if pn.Spec.Vlan.LogicalInterfaceName != "" {
+		return pn.Spec.Vlan.LogicalInterfaceName
+	}
+	return ovn.DefaultVlanIntfName(lsName, pn.Spec.Vlan.VlanId)
+}
+
+func createVlanMsg(pn *v1alpha1.ProviderNetwork, lsName string) pb.Notification {
 	msg := pb.Notification{
 		CniType: "ovn4nfv",
 		Payload: &pb.Notification_ProviderNwCreate{
 			ProviderNwCreate: &pb.ProviderNetworkCreate{
-				ProviderNwName: pn.Name,
+				ProviderNwName: lsName,
 				Vlan: &pb.VlanInfo{
 					VlanId:       pn.Spec.Vlan.VlanId,
 					ProviderIntf: pn.Spec.Vlan.ProviderInterfaceName,
@@ -134,25 +361,25 @@ func createVlanMsg(pn *v1alpha1.ProviderNetwork) pb.Notification {
 	return msg
 }
 
-func deleteVlanMsg(pn *v1alpha1.ProviderNetwork) pb.Notification {
+func deleteVlanMsg(pn *v1alpha1.ProviderNetwork, lsName string) pb.Notification {
 	msg := pb.Notification{
 		CniType: "ovn4nfv",
 		Payload: &pb.Notification_ProviderNwRemove{
 			ProviderNwRemove: &pb.ProviderNetworkRemove{
-				ProviderNwName:  pn.Name,
-				VlanLogicalIntf: pn.Spec.Vlan.LogicalInterfaceName,
+				ProviderNwName:  lsName,
+				VlanLogicalIntf: vlanLogicalIntf(pn, lsName),
 			},
 		},
 	}
 	return msg
 }
 
-func createDirectMsg(pn *v1alpha1.ProviderNetwork) pb.Notification {
+func createDirectMsg(pn *v1alpha1.ProviderNetwork, lsName string) pb.Notification {
 	msg := pb.Notification{
 		CniType: "ovn4nfv",
 		Payload: &pb.Notification_ProviderNwCreate{
 			ProviderNwCreate: &pb.ProviderNetworkCreate{
-				ProviderNwName: pn.Name,
+				ProviderNwName: lsName,
 				Direct: &pb.DirectInfo{
 					ProviderIntf: pn.Spec.Direct.ProviderInterfaceName,
 				},
@@ -162,12 +389,12 @@ func createDirectMsg(pn *v1alpha1.ProviderNetwork) pb.Notification {
 	return msg
 }
 
-func deleteDirectMsg(pn *v1alpha1.ProviderNetwork) pb.Notification {
+func deleteDirectMsg(pn *v1alpha1.ProviderNetwork, lsName string) pb.Notification {
 	msg := pb.Notification{
 		CniType: "ovn4nfv",
 		Payload: &pb.Notification_ProviderNwRemove{
 			ProviderNwRemove: &pb.ProviderNetworkRemove{
-				ProviderNwName:     pn.Name,
+				ProviderNwName:     lsName,
 				DirectProviderIntf: pn.Spec.Direct.ProviderInterfaceName,
 			},
 		},
@@ -178,16 +405,22 @@ func deleteDirectMsg(pn *v1alpha1.ProviderNetwork) pb.Notification {
 //SendNotif to client
 func SendNotif(pn *v1alpha1.ProviderNetwork, msgType string, nodeReq string) error {
 	var msg pb.Notification
-	var err error
+	var sent map[string]uint64
+
+	// Nodes name the bridge and VLAN interface after the logical switch
+	lsName, err := ovn.ProviderNetworkSwitch(pn.Namespace, pn.Name)
+	if err != nil {
+		return err
+	}
 
 	switch {
 	case pn.Spec.CniType == "ovn4nfv":
 		switch {
 		case pn.Spec.ProviderNetType == "VLAN":
 			if msgType == "create" {
-				msg = createVlanMsg(pn)
+				msg = createVlanMsg(pn, lsName)
 			} else if msgType == "delete" {
-				msg = deleteVlanMsg(pn)
+				msg = deleteVlanMsg(pn, lsName)
 			}
 			if strings.EqualFold(pn.Spec.Vlan.VlanNodeSelector, "SPECIFIC") {
 				for _, label := range pn.Spec.Vlan.NodeLabelList {
@@ -198,22 +431,17 @@ func SendNotif(pn *v1alpha1.ProviderNetwork, msgType string, nodeReq string) err
 					}
 				}
 				labels := strings.Join(pn.Spec.Vlan.NodeLabelList[:], ",")
-				err = sendMsg(msg, labels, "specific", nodeReq)
+				sent, err = sendMsg(msg, labels, "specific", nodeReq)
 			} else if strings.EqualFold(pn.Spec.Vlan.VlanNodeSelector, "ALL") {
-				err = sendMsg(msg, "", "all", nodeReq)
+				sent, err = sendMsg(msg, "", "all", nodeReq)
 			} else if strings.EqualFold(pn.Spec.Vlan.VlanNodeSelector, "ANY") {
-				if pn.Status.State != v1alpha1.Created {
-					err = sendMsg(msg, "", "any", nodeReq)
-					if err == nil {
-						updatePnStatus(pn, v1alpha1.Created)
-					}
-				}
+				sent, err = sendAnyMsg(msg, pn)
 			}
 		case pn.Spec.ProviderNetType == "DIRECT":
 			if msgType == "create" {
-				msg = createDirectMsg(pn)
+				msg = createDirectMsg(pn, lsName)
 			} else if msgType == "delete" {
-				msg = deleteDirectMsg(pn)
+				msg = deleteDirectMsg(pn, lsName)
 			}
 			if strings.EqualFold(pn.Spec.Direct.DirectNodeSelector, "SPECIFIC") {
 				for _, label := range pn.Spec.Direct.NodeLabelList {
@@ -224,16 +452,11 @@ func SendNotif(pn *v1alpha1.ProviderNetwork, msgType string, nodeReq string) err
 					}
 				}
 				labels := strings.Join(pn.Spec.Direct.NodeLabelList[:], ",")
-				err = sendMsg(msg, labels, "specific", nodeReq)
+				sent, err = sendMsg(msg, labels, "specific", nodeReq)
 			} else if strings.EqualFold(pn.Spec.Direct.DirectNodeSelector, "ALL") {
-				err = sendMsg(msg, "", "all", nodeReq)
+				sent, err = sendMsg(msg, "", "all", nodeReq)
 			} else if strings.EqualFold(pn.Spec.Direct.DirectNodeSelector, "ANY") {
-				if pn.Status.State != v1alpha1.Created {
-					err = sendMsg(msg, "", "any", nodeReq)
-					if err == nil {
-						updatePnStatus(pn, v1alpha1.Created)
-					}
-				}
+				sent, err = sendAnyMsg(msg, pn)
 			}
 		default:
 			return fmt.Errorf("Unsupported Provider Network type")
@@ -241,35 +464,34 @@ func SendNotif(pn *v1alpha1.ProviderNetwork, msgType string, nodeReq string) err
 	default:
 		return fmt.Errorf("Unsupported CNI type")
 	}
+	if nodeReq == "" {
+		switch msgType {
+		case "create":
+			recordSent(pn, sent)
+		case "delete":
+			forgetSent(pn)
+		}
+	}
 	return err
 }
 
-// sendMsg send notification to client
-func sendMsg(msg pb.Notification, labels string, option string, nodeReq string) error {
+// sendMsg send notification to client, it returns the generation of the
+// message sent to each node
+func sendMsg(msg pb.Notification, labels string, option string, nodeReq string) (map[string]uint64, error) {
+	sent := make(map[string]uint64)
 	if option == "all" {
-		for name, client := range notifServer.clientList {
+		for name, client := range notifServer.getClients() {
 			if nodeReq != "" && nodeReq != name {
 				continue
 			}
-			if client.stream != nil {
-				if err := client.stream.Send(&msg); err != nil {
-					log.Error(err, "Msg Send failed", "Node name", name)
-				}
-			}
-		}
-		return nil
-	} else if option == "any" {
-		// Always select the first
-		for _, client := range notifServer.clientList {
-			if client.stream != nil {
-				if err := client.stream.Send(&msg); err != nil {
-					return err
-				}
-				// return after first successful send
-				return nil
+			m := msg
+			if err := client.send(&m); err != nil {
+				log.Error(err, "Msg Send failed", "Node name", name)
+				continue
 			}
+			sent[name] = m.Generation
 		}
-		return nil
+		return sent, nil
 	}
 	// This is specific case
 	for name := range nodeListIterator(labels) {
@@ -277,64 +499,155 @@ func sendMsg(msg pb.Notification, labels string, option string, nodeReq string)
 			continue
 		}
 		client := notifServer.GetClient(name)
-		if client.stream != nil {
-			if err := client.stream.Send(&msg); err != nil {
-				return err
+		if client != nil {
+			m := msg
+			if err := client.send(&m); err != nil {
+				return sent, err
 			}
+			sent[name] = m.Generation
 		}
 	}
-	return nil
+	return sent, nil
+}
+
+// sendAnyMsg sends notification to the node selected for the provider
+// network. If no node is selected yet the first node is selected and
+// recorded in the provider network status.
+func sendAnyMsg(msg pb.Notification, pn *v1alpha1.ProviderNetwork) (map[string]uint64, error) {
+	sent := make(map[string]uint64)
+	if pn.Status.Node != "" {
+		client := notifServer.GetClient(pn.Status.Node)
+		if client != nil {
+			if err := client.send(&msg); err != nil {
+				return sent, err
+			}
+			sent[pn.Status.Node] = msg.Generation
+		}
+		return sent, nil
+	}
+	// Always select the first
+	for name, client := range notifServer.getClients() {
+		m := msg
+		if err := client.send(&m); err != nil {
+			return sent, err
+		}
+		// return after first successful send
+		pn.Status.Node = name
+		sent[name] = m.Generation
+		return sent, nil
+	}
+	return sent, nil
 }
 
-//SendProviderNotif to client
-func SendRouteNotif(chainRoutingInfo []chaining.RoutingInfo, msgType string) error {
+//SendRouteNotif sends the routes of the chain to be inserted in or removed
+//from the pods
+func SendRouteNotif(chain string, chainRoutingInfo []chaining.RoutingInfo, msgType string) error {
 	var msg pb.Notification
 	var err error
-	var ins pb.ContainerRouteInsert
 
 	for _, r := range chainRoutingInfo {
-		ins.ContainerId = r.Id
-		ins.Route = nil
-
-		rt := &pb.RouteData{
-			Dst: r.LeftNetworkRoute.Dst,
-			Gw:  r.LeftNetworkRoute.GW,
-		}
-		ins.Route = append(ins.Route, rt)
-
-		rt = &pb.RouteData{
-			Dst: r.RightNetworkRoute.Dst,
-			Gw:  r.RightNetworkRoute.GW,
-		}
-		ins.Route = append(ins.Route, rt)
-
-		for _, d := range r.DynamicNetworkRoutes {
-			rt = &pb.RouteData{
-				Dst: d.Dst,
-				Gw:  d.GW,
-			}
-			ins.Route = append(ins.Route, rt)
-		}
-		if msgType == "create" {
+		routes := r.RouteData()
+		switch msgType {
+		case "create":
 			msg = pb.Notification{
 				CniType: "ovn4nfv",
 				Payload: &pb.Notification_ContainterRtInsert{
-					ContainterRtInsert: &ins,
+					ContainterRtInsert: &pb.ContainerRouteInsert{
+						ContainerId:  r.Id,
+						PodNamespace: r.Namespace,
+						PodName:      r.Name,
+						Route:        routes,
+						Chain:        chain,
+					},
 				},
 			}
+		case "delete":
+			msg = pb.Notification{
+				CniType: "ovn4nfv",
+				Payload: &pb.Notification_ContainterRtRemove{
+					ContainterRtRemove: &pb.ContainerRouteRemove{
+						ContainerId:  r.Id,
+						PodNamespace: r.Namespace,
+						PodName:      r.Name,
+						Route:        routes,
+						LeaveChain:   r.LeaveChain,
+					},
+				},
+			}
+		default:
+			return fmt.Errorf("Unsupported message type %s", msgType)
 		}
 		client := notifServer.GetClient(r.Node)
-		if client.stream != nil {
-			if err := client.stream.Send(&msg); err != nil {
-				log.Error(err, "Failed to send msg", "Node", r.Node)
-				return err
-			}
+		if client == nil {
+			err = fmt.Errorf("Node %s is not subscribed", r.Node)
+			log.Error(err, "Failed to send msg", "Node", r.Node)
+			continue
+		}
+		if err := client.send(&msg); err != nil {
+			log.Error(err, "Failed to send msg", "Node", r.Node)
+			return err
 		}
-		// TODO: Handle Delete
 	}
 	return err
 }
 
+// SendPodInterfaceNotif sends the interfaces hot-plugged in and unplugged
+// from the pod to the agent of its node. Removals are applied first so that
+// an interface moved to another network is plugged again. The interfaces of
+// the generated NetworkAttachmentDefinitions get their MTU.
+func SendPodInterfaceNotif(pod *corev1.Pod, added []podnetwork.InterfaceResult, removed []string) error {
+	client := notifServer.GetClient(pod.Spec.NodeName)
+	if client == nil {
+		return fmt.Errorf("Node %s is not subscribed", pod.Spec.NodeName)
+	}
+	if len(removed) > 0 {
+		msg := pb.Notification{
+			CniType: "ovn4nfv",
+			Payload: &pb.Notification_PodIntfRemove{
+				PodIntfRemove: &pb.PodInterfaceRemove{
+					PodNamespace: pod.Namespace,
+					PodName:      pod.Name,
+					Interface:    removed,
+				},
+			},
+		}
+		if err := client.send(&msg); err != nil {
+			log.Error(err, "Failed to send msg", "Node", pod.Spec.NodeName)
+			return err
+		}
+	}
+	if len(added) > 0 {
+		var interfaces []*pb.PodInterface
+		for _, intf := range added {
+			podIntf := &pb.PodInterface{
+				Interface:  intf.Interface,
+				IpAddress:  intf.IPAddress,
+				MacAddress: intf.MacAddress,
+				GatewayIp:  intf.GatewayIP,
+			}
+			if intf.Network != "" {
+				podIntf.Mtu = int32(netattachdef.MTU)
+			}
+			interfaces = append(interfaces, podIntf)
+		}
+		msg := pb.Notification{
+			CniType: "ovn4nfv",
+			Payload: &pb.Notification_PodIntfAdd{
+				PodIntfAdd: &pb.PodInterfaceAdd{
+					PodNamespace: pod.Namespace,
+					PodName:      pod.Name,
+					Interface:    interfaces,
+				},
+			},
+		}
+		if err := client.send(&msg); err != nil {
+			log.Error(err, "Failed to send msg", "Node", pod.Spec.NodeName)
+			return err
+		}
+	}
+	return nil
+}
+
 func nodeListIterator(labels string) <-chan string {
 	ch := make(chan string)
 
@@ -343,7 +656,8 @@ func nodeListIterator(labels string) <-chan string {
 	nodes, err := kubeClientset.CoreV1().Nodes().List(lo)
 	if err != nil {
 		log.Info("No Nodes found with labels", "list:", lo)
-		return nil
+		close(ch)
+		return ch
 	}
 	go func() {
 		for _, node := range nodes.Items {
@@ -355,8 +669,10 @@ func nodeListIterator(labels string) <-chan string {
 	return ch
 }
 
-//SetupNotifServer initilizes the gRpc nfn notif server
-func SetupNotifServer(kConfig *rest.Config) {
+//SetupNotifServer initilizes the gRpc nfn notif server and serves
+//till the stop channel is closed. It is run only on the leader so agents
+//are always connected to the operator replica that owns the OVN state.
+func SetupNotifServer(kConfig *rest.Config, stop <-chan struct{}) error {
 
 	log.Info("Starting Notif Server")
 	var err error
@@ -365,10 +681,12 @@ func SetupNotifServer(kConfig *rest.Config) {
 	pnClientset, err = clientset.NewForConfig(kConfig)
 	if err != nil {
 		log.Error(err, "Error building clientset")
+		return err
 	}
 	kubeClientset, err = kubernetes.NewForConfig(kConfig)
 	if err != nil {
 		log.Error(err, "Error building Kuberenetes clientset")
+		return err
 	}
 
 	stopChan = make(chan interface{})
@@ -377,16 +695,28 @@ func SetupNotifServer(kConfig *rest.Config) {
 	lis, err := net.Listen("tcp", ":50000")
 	if err != nil {
 		log.Error(err, "failed to listen")
+		return err
 	}
 
-	s := grpc.NewServer()
-	// Intialize Notify server
-	notifServer = newServer()
+	// Agents send keepalive pings to detect a dead operator
+	s := grpc.NewServer(grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             10 * time.Second,
+		PermitWithoutStream: true,
+	}))
 	pb.RegisterNfnNotifyServer(s, notifServer)
 
 	reflection.Register(s)
+	go func() {
+		<-stop
+		log.Info("Stopping Notif Server")
+		// Release Subscribe streams so that agents reconnect
+		close(stopChan)
+		s.GracefulStop()
+	}()
 	log.Info("Initialization Completed")
 	if err := s.Serve(lis); err != nil {
 		log.Error(err, "failed to serve")
+		return err
 	}
+	return nil
 }
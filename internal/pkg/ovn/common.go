@@ -17,6 +17,8 @@
 package ovn
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/vishvananda/netlink"
@@ -30,6 +32,111 @@ import (
 
 var log = logf.Log.WithName("ovn")
 
+// maxIntfNameLen is the maximum length of a Linux interface name
+const maxIntfNameLen = 15
+
+// ProviderNetworkName returns the logical switch name of a provider network.
+// Provider networks in the default namespace keep their name, others are
+// prefixed with the namespace. "_" is not valid in Kubernetes names so the
+// names can't collide.
+func ProviderNetworkName(namespace, name string) string {
+	if namespace == "" || namespace == "default" {
+		return name
+	}
+	return namespace + "_" + name
+}
+
+// providerNetworkExternalID marks the logical switch of a provider network
+// with the namespace/name of the provider network
+const providerNetworkExternalID = "provider_network"
+
+// providerNetworkOwner returns the namespace/name of the provider network
+func providerNetworkOwner(namespace, name string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return namespace + "/" + name
+}
+
+// ProviderNetworkSwitch returns the logical switch of the provider network.
+// The switches of the provider networks outside the default namespace
+// created before the namespace prefix are named after the provider network
+// only, they keep their name: a switch with the localnet port of the
+// provider network name not claimed by another provider network.
+func ProviderNetworkSwitch(namespace, name string) (string, error) {
+	lsName := ProviderNetworkName(namespace, name)
+	if lsName == name {
+		return lsName, nil
+	}
+	// The switch of the new name takes precedence
+	exists, err := localnetPortExists(lsName)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return lsName, nil
+	}
+	legacy, err := localnetPortExists(name)
+	if err != nil {
+		return "", err
+	}
+	if !legacy {
+		return lsName, nil
+	}
+	owner, stderr, err := RunOVNNbctl("--if-exists", "get", "logical_switch", name,
+		"external_ids:"+providerNetworkExternalID)
+	if err != nil {
+		log.Error(err, "Failed to get switch provider network", "name", name, "stderr", stderr)
+		return "", err
+	}
+	if owner != "" && owner != providerNetworkOwner(namespace, name) {
+		return lsName, nil
+	}
+	return name, nil
+}
+
+// localnetPortExists returns true if the switch has the localnet port of a
+// provider network
+func localnetPortExists(lsName string) (bool, error) {
+	stdout, stderr, err := RunOVNNbctl("--data=bare", "--no-heading",
+		"--columns=name", "find", "logical_switch_port", "name=server-localnet_"+lsName)
+	if err != nil {
+		log.Error(err, "Failed to find localnet port", "name", lsName, "stderr", stderr)
+		return false, err
+	}
+	return stdout != "", nil
+}
+
+// SplitProviderNetworkName returns the namespace and the name of the
+// provider network of the logical switch name built by ProviderNetworkName
+func SplitProviderNetworkName(nwName string) (namespace, name string) {
+	if i := strings.Index(nwName, "_"); i >= 0 {
+		return nwName[:i], nwName[i+1:]
+	}
+	return "default", nwName
+}
+
+// PnBridgeName returns the OVS bridge name for the provider network. Names
+// too long for an interface name are hashed.
+func PnBridgeName(nwName string) string {
+	return intfName("br-", nwName, "")
+}
+
+// DefaultVlanIntfName returns the VLAN interface name used when the provider
+// network doesn't set a logical interface name
+func DefaultVlanIntfName(nwName, vlanID string) string {
+	return intfName("", nwName, "."+vlanID)
+}
+
+func intfName(prefix, name, suffix string) string {
+	if len(prefix+name+suffix) <= maxIntfNameLen {
+		return prefix + name + suffix
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])
+	return prefix + hash[:maxIntfNameLen-len(prefix)-len(suffix)] + suffix
+}
+
 // CreateVlan creates VLAN with vlanID
 func CreateVlan(vlanID, interfaceName, logicalInterfaceName string) error {
 	if interfaceName == "" || vlanID == "" || logicalInterfaceName == "" {
@@ -127,8 +234,9 @@ func DeletePnBridge(nwName, brName string) error {
 	return nil
 }
 
-// GetPnBridge returns Provider networks with external ids
-func GetPnBridge(externalID string) []string {
+// GetPnBridge returns Provider network bridges with external ids mapped to
+// the external id value
+func GetPnBridge(externalID string) map[string]string {
 	if externalID == "" {
 		log.Error(fmt.Errorf("GetBridge invalid parameters"), "Invalid")
 	}
@@ -138,7 +246,7 @@ func GetPnBridge(externalID string) []string {
 		return nil
 	}
 	brNames := strings.Split(stdout, "\n")
-	var brList []string
+	brList := make(map[string]string)
 	for _, name := range brNames {
 		stdout, stderr, err = RunOVSVsctl("get", "bridge", name, "external_ids:"+externalID)
 		if err != nil {
@@ -150,7 +258,7 @@ func GetPnBridge(externalID string) []string {
 		if stdout == "" {
 			continue
 		} else {
-			brList = append(brList, name)
+			brList[name] = strings.Trim(stdout, "\"")
 		}
 	}
 	return brList
@@ -234,7 +342,7 @@ func setupDistributedRouter(name string) error {
 	}
 	// Create a logical switch called "ovn4nfv-join" that will be used to connect gateway routers to the distributed router.
 	// The "ovn4nfv-join" will be allocated IP addresses in the range 100.64.1.0/24.
-	stdout, stderr, err = RunOVNNbctl("--may-exist", "ls-add", "ovn4nfv-join")
+	stdout, stderr, err = RunOVNNbctl("--may-exist", "ls-add", JoinSwitch)
 	if err != nil {
 		log.Error(err, "Failed to create logical switch called \"ovn4nfv-join\"", "stdout", stdout, "stderr", stderr)
 		return err
@@ -254,7 +362,7 @@ func setupDistributedRouter(name string) error {
 		}
 	}
 	// Connect the switch "ovn4nfv-join" to the router.
-	stdout, stderr, err = RunOVNNbctl("--", "--may-exist", "lsp-add", "ovn4nfv-join", "jtor-"+name, "--", "set", "logical_switch_port", "jtor-"+name, "type=router", "options:router-port=rtoj-"+name, "addresses="+"\""+routerMac+"\"")
+	stdout, stderr, err = RunOVNNbctl("--", "--may-exist", "lsp-add", JoinSwitch, "jtor-"+name, "--", "set", "logical_switch_port", "jtor-"+name, "type=router", "options:router-port=rtoj-"+name, "addresses="+"\""+routerMac+"\"")
 	if err != nil {
 		log.Error(err, "Failed to add logical switch port to logical router", "stdout", stdout, "stderr", stderr)
 		return err
@@ -352,6 +460,22 @@ func GetNetworkSubnet(nw string) (string, error) {
         return stdout, nil
 }
 
+// GetNetworkGateway returns the gateway IP address of the network, the
+// address of the cluster router on the network
+func GetNetworkGateway(nw string) (string, error) {
+        stdout, stderr, err := RunOVNNbctl("--if-exists",
+                "get", "logical_switch", nw,
+                "external_ids:gateway_ip")
+        if err != nil {
+                log.Error(err, "Failed to get gateway for network", "stderr", stderr, "stdout", stdout)
+                return "", err
+        }
+        if stdout == "" {
+                return "", fmt.Errorf("No gateway for network %s", nw)
+        }
+        return strings.Split(stdout, "/")[0], nil
+}
+
 func GetIPAdressForPod(nw string, name string) (string, error) {
         _, stderr, err := RunOVNNbctl("--data=bare", "--no-heading",
                 "--columns=name", "find", "logical_switch", "name="+nw)
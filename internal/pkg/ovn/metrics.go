@@ -0,0 +1,108 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ovn
+
+import (
+	"encoding/csv"
+	"net"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var allocatedIPsDesc = prometheus.NewDesc("ovn4nfv_network_allocated_ips",
+	"Number of pod IP addresses allocated per network", []string{"network"}, nil)
+
+// ipCollector counts the pod addresses of each logical switch when scraped
+type ipCollector struct{}
+
+// RegisterIPMetrics registers the per network allocated IP counts
+func RegisterIPMetrics() error {
+	return metrics.Registry.Register(ipCollector{})
+}
+
+func (ipCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- allocatedIPsDesc
+}
+
+func (ipCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := allocatedIPs()
+	if err != nil {
+		log.Error(err, "Failed to count allocated IPs")
+		return
+	}
+	for network, count := range counts {
+		ch <- prometheus.MustNewConstMetric(allocatedIPsDesc, prometheus.GaugeValue, float64(count), network)
+	}
+}
+
+// allocatedIPs returns the number of pod ports with an address per logical
+// switch
+func allocatedIPs() (map[string]int, error) {
+	stdout, stderr, err := RunOVNNbctl("--data=bare", "--no-heading", "--format=csv",
+		"--columns=_uuid,addresses,dynamic_addresses", "find", "logical_switch_port", "external_ids:pod=true")
+	if err != nil {
+		log.Error(err, "Failed to list pod ports", "stderr", stderr)
+		return nil, err
+	}
+	rows, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	withIP := make(map[string]bool)
+	for _, row := range rows {
+		if len(row) == 3 && (hasIP(row[1]) || hasIP(row[2])) {
+			withIP[row[0]] = true
+		}
+	}
+
+	stdout, stderr, err = RunOVNNbctl("--data=bare", "--no-heading", "--format=csv",
+		"--columns=name,ports", "list", "logical_switch")
+	if err != nil {
+		log.Error(err, "Failed to list logical switches", "stderr", stderr)
+		return nil, err
+	}
+	rows, err = csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, row := range rows {
+		if len(row) != 2 {
+			continue
+		}
+		counts[row[0]] = 0
+		for _, port := range strings.Fields(row[1]) {
+			if withIP[port] {
+				counts[row[0]]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// hasIP returns whether OVN port addresses such as "0a:00:00:00:00:01
+// 10.0.0.5" include an IP address
+func hasIP(addresses string) bool {
+	for _, field := range strings.Fields(addresses) {
+		if net.ParseIP(field) != nil {
+			return true
+		}
+	}
+	return false
+}
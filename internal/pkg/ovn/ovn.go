@@ -5,11 +5,11 @@ import (
 	"math/rand"
 	"os"
 	"ovn4nfv-k8s-plugin/internal/pkg/config"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
 	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
 	"strings"
 	"time"
 
-	"github.com/mitchellh/mapstructure"
 	kapi "k8s.io/api/core/v1"
 	kexec "k8s.io/utils/exec"
 )
@@ -27,9 +27,13 @@ type OVNNetworkConf struct {
 const (
 	ovn4nfvRouterName = "ovn4nfv-master"
 	// Ovn4nfvAnnotationTag tag on already processed Pods
-	Ovn4nfvAnnotationTag = "k8s.plugin.opnfv.org/ovnInterfaces"
+	Ovn4nfvAnnotationTag = podnetwork.InterfacesAnnotation
 	// OVN Default Network name
 	Ovn4nfvDefaultNw = "ovn4nfvk8s-default-nw"
+	// JoinSwitch connects the gateway routers to the distributed router
+	JoinSwitch = "ovn4nfv-join"
+	// JoinSubnet is the subnet of the join switch
+	JoinSubnet = "100.64.1.0/24"
 )
 
 var ovnConf *OVNNetworkConf
@@ -56,16 +60,6 @@ func GetOvnNetConf() error {
 	return nil
 }
 
-type netInterface struct {
-	Name           string
-	Interface      string
-	NetType        string
-	DefaultGateway string
-	IPAddress      string
-	MacAddress     string
-	GWIPaddress    string
-}
-
 var ovnCtl *Controller
 
 // NewOvnController creates a new OVN controller for creating logical networks
@@ -116,8 +110,9 @@ func (oc *Controller) AddNodeLogicalPorts(node string) (ipAddr, macAddr string,
 	return ipAddr, macmacAddr, nil
 }
 
-// AddLogicalPorts adds ports to the Pod
-func (oc *Controller) AddLogicalPorts(pod *kapi.Pod, ovnNetObjs []map[string]interface{}) (key, value string) {
+// AddLogicalPorts adds ports to the Pod and returns the annotations listing
+// them. The error tells why the ports of the pod could not be added.
+func (oc *Controller) AddLogicalPorts(pod *kapi.Pod, interfaces []podnetwork.InterfaceRequest) (annotations map[string]string, err error) {
 
 	if pod.Spec.HostNetwork {
 		return
@@ -128,69 +123,202 @@ func (oc *Controller) AddLogicalPorts(pod *kapi.Pod, ovnNetObjs []map[string]int
 		return
 	}
 
-	var ovnString, outStr string
+	var results []podnetwork.InterfaceResult
+	var networks []string
 	var defaultInterface bool
 
-	ovnString = "["
-	var ns netInterface
-	for _, net := range ovnNetObjs {
-		err := mapstructure.Decode(net, &ns)
+	for _, ns := range interfaces {
+		lsName, err := oc.interfaceSwitch(pod, ns)
 		if err != nil {
-			log.Error(err, "mapstruct error", "network", net)
-			return
-		}
-		if !oc.FindLogicalSwitch(ns.Name) {
-			log.Info("Logical Switch not found")
-			return
+			return nil, err
 		}
 		if ns.Name == Ovn4nfvDefaultNw {
 			defaultInterface = true
 		}
-		if ns.Interface == "" && ns.Name != Ovn4nfvDefaultNw {
-			log.Info("Interface name must be provided")
-			return
-		}
-		if ns.DefaultGateway == "" {
-			ns.DefaultGateway = "false"
-		}
-		var portName string
-		if ns.Interface != "" {
-			portName = fmt.Sprintf("%s_%s_%s", pod.Namespace, pod.Name, ns.Interface)
-		} else {
-			portName = fmt.Sprintf("%s_%s", pod.Namespace, pod.Name)
-			ns.Interface = "*"
-		}
-		outStr = oc.addLogicalPortWithSwitch(pod, ns.Name, ns.IPAddress, ns.MacAddress, ns.GWIPaddress, portName)
-		if outStr == "" {
-			return
+		result, err := oc.addInterface(pod, lsName, ns)
+		if err != nil {
+			return nil, err
 		}
-		last := len(outStr) - 1
-		tmpString := outStr[:last]
-		tmpString += "," + "\\\"defaultGateway\\\":" + "\\\"" + ns.DefaultGateway + "\\\""
-		tmpString += "," + "\\\"interface\\\":" + "\\\"" + ns.Interface + "\\\"}"
-		ovnString += tmpString
-		ovnString += ","
-	}
-	var last int
+		results = append(results, *result)
+		networks = append(networks, statusName(ns, lsName))
+	}
 	if defaultInterface == false {
 		// Add Default interface
 		portName := fmt.Sprintf("%s_%s", pod.Namespace, pod.Name)
-		outStr = oc.addLogicalPortWithSwitch(pod, Ovn4nfvDefaultNw, "", "", "", portName)
-		if outStr == "" {
-			return
+		result := oc.addLogicalPortWithSwitch(pod, Ovn4nfvDefaultNw, "", "", "", portName)
+		if result == nil {
+			return nil, fmt.Errorf("Failed to add logical port %s to switch %s", portName, Ovn4nfvDefaultNw)
 		}
-		last := len(outStr) - 1
-		tmpString := outStr[:last]
-		tmpString += "," + "\\\"interface\\\":" + "\\\"" + "*" + "\\\"}"
-		ovnString += tmpString
-		ovnString += ","
-	}
-	last = len(ovnString) - 1
-	ovnString = ovnString[:last]
-	ovnString += "]"
-	key = Ovn4nfvAnnotationTag
-	value = ovnString
-	return key, value
+		result.Interface = podnetwork.DefaultInterface
+		results = append(results, *result)
+		networks = append(networks, Ovn4nfvDefaultNw)
+	}
+	return interfaceAnnotations(networks, results)
+}
+
+// UpdateLogicalPorts adds the ports of the interfaces requested for a pod
+// already annotated with its interfaces and deletes the ports of the
+// interfaces no longer requested. Interfaces are matched by name, an interface
+// moved to another network is removed and added again. The default interface
+// is left alone. It returns the annotations listing the ports along with the
+// interfaces added and the names of the interfaces removed.
+func (oc *Controller) UpdateLogicalPorts(pod *kapi.Pod, interfaces []podnetwork.InterfaceRequest) (annotations map[string]string, added []podnetwork.InterfaceResult, removed []string, err error) {
+	current, err := podnetwork.ParseInterfaces(pod.Annotations[Ovn4nfvAnnotationTag])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	requested := make(map[string]podnetwork.InterfaceRequest)
+	switches := make(map[string]string)
+	for _, ns := range interfaces {
+		if ns.Interface == "" || ns.Interface == podnetwork.DefaultInterface {
+			continue
+		}
+		lsName, err := oc.interfaceSwitch(pod, ns)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		requested[ns.Interface] = ns
+		switches[ns.Interface] = lsName
+	}
+
+	var results []podnetwork.InterfaceResult
+	var networks []string
+	kept := make(map[string]bool)
+	for _, intf := range current {
+		portName := interfacePortName(pod, intf.Interface)
+		lsName := portSwitch(portName)
+		if intf.Interface == podnetwork.DefaultInterface {
+			if lsName == "" {
+				lsName = Ovn4nfvDefaultNw
+			}
+		} else if _, ok := requested[intf.Interface]; !ok || lsName != switches[intf.Interface] {
+			log.Info("Deleting logical port of unplugged interface", "port", portName, "interface", intf.Interface)
+			stdout, stderr, err := RunOVNNbctl("--if-exists", "lsp-del", portName)
+			if err != nil {
+				log.Error(err, "Error in deleting pod's logical port ", "stdout", stdout, "stderr", stderr)
+				return nil, nil, nil, fmt.Errorf("Failed to delete logical port %s", portName)
+			}
+			removed = append(removed, intf.Interface)
+			continue
+		}
+		kept[intf.Interface] = true
+		results = append(results, intf)
+		networks = append(networks, statusName(requested[intf.Interface], lsName))
+	}
+	for _, ns := range interfaces {
+		if _, ok := requested[ns.Interface]; !ok || kept[ns.Interface] {
+			continue
+		}
+		lsName := switches[ns.Interface]
+		// The default route of a running pod is not moved
+		ns.DefaultGateway = "false"
+		result, err := oc.addInterface(pod, lsName, ns)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		kept[ns.Interface] = true
+		results = append(results, *result)
+		networks = append(networks, statusName(ns, lsName))
+		added = append(added, *result)
+	}
+	annotations, err = interfaceAnnotations(networks, results)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return annotations, added, removed, nil
+}
+
+// interfaceSwitch returns the logical switch of the network of the interface
+func (oc *Controller) interfaceSwitch(pod *kapi.Pod, ns podnetwork.InterfaceRequest) (string, error) {
+	namespace := ns.Namespace
+	if namespace == "" {
+		namespace = pod.Namespace
+	}
+	// Provider network in the pod namespace takes precedence
+	lsName := ProviderNetworkName(namespace, ns.Name)
+	if lsName != ns.Name && !oc.FindLogicalSwitch(lsName) {
+		lsName = ns.Name
+	}
+	if !oc.FindLogicalSwitch(lsName) {
+		log.Info("Logical Switch not found", "name", ns.Name)
+		return "", fmt.Errorf("Logical Switch not found for network %s", ns.Name)
+	}
+	return lsName, nil
+}
+
+// addInterface adds the port of the interface to the logical switch
+func (oc *Controller) addInterface(pod *kapi.Pod, lsName string, ns podnetwork.InterfaceRequest) (*podnetwork.InterfaceResult, error) {
+	if ns.Interface == "" && ns.Name != Ovn4nfvDefaultNw {
+		log.Info("Interface name must be provided")
+		return nil, fmt.Errorf("Interface name must be provided for network %s", ns.Name)
+	}
+	if ns.DefaultGateway == "" {
+		ns.DefaultGateway = "false"
+	}
+	if ns.Interface == "" {
+		ns.Interface = podnetwork.DefaultInterface
+	}
+	portName := interfacePortName(pod, ns.Interface)
+	result := oc.addLogicalPortWithSwitch(pod, lsName, ns.IPAddress, ns.MacAddress, ns.GWIPAddress, portName)
+	if result == nil {
+		return nil, fmt.Errorf("Failed to add logical port %s to switch %s", portName, lsName)
+	}
+	result.DefaultGateway = ns.DefaultGateway
+	result.Interface = ns.Interface
+	if ns.NetAttachDef != "" {
+		result.Network = ns.Name
+	}
+	return result, nil
+}
+
+// interfacePortName returns the name of the logical port of the pod interface
+func interfacePortName(pod *kapi.Pod, intf string) string {
+	if intf == podnetwork.DefaultInterface {
+		return fmt.Sprintf("%s_%s", pod.Namespace, pod.Name)
+	}
+	return fmt.Sprintf("%s_%s_%s", pod.Namespace, pod.Name, intf)
+}
+
+// portSwitch returns the logical switch of the pod logical port, empty if the
+// port does not exist
+func portSwitch(portName string) string {
+	stdout, _, err := RunOVNNbctl("--if-exists", "get", "logical_switch_port", portName, "external_ids:logical_switch")
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(stdout, `"`)
+}
+
+// interfaceAnnotations returns the annotations listing the interfaces of the
+// pod and their networks
+func interfaceAnnotations(networks []string, results []podnetwork.InterfaceResult) (map[string]string, error) {
+	value, err := podnetwork.MarshalInterfaces(results)
+	if err != nil {
+		return nil, err
+	}
+	status, err := podnetwork.MarshalNetworkStatus(podnetwork.NetworkStatuses(networks, results))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		Ovn4nfvAnnotationTag:               value,
+		podnetwork.NetworkStatusAnnotation: status,
+	}, nil
+}
+
+// statusName returns the name of the network of the interface in the
+// network-status annotation: the NetworkAttachmentDefinition selecting it as
+// Multus names them, else the network of the logical switch, namespace/name
+// for provider networks
+func statusName(ns podnetwork.InterfaceRequest, lsName string) string {
+	if ns.NetAttachDef != "" {
+		return ns.NetAttachDef
+	}
+	namespace, name := SplitProviderNetworkName(lsName)
+	if namespace == "" || namespace == "default" {
+		return name
+	}
+	return namespace + "/" + name
 }
 
 // DeleteLogicalPorts deletes the OVN ports for the pod
@@ -285,20 +413,25 @@ func (oc *Controller) CreateProviderNetwork(cr *k8sv1alpha1.ProviderNetwork) err
 	var stdout, stderr string
 
 	// Currently only these fields are supported
-	name := cr.Name
+	name, err := ProviderNetworkSwitch(cr.Namespace, cr.Name)
+	if err != nil {
+		return err
+	}
 	subnet := cr.Spec.Ipv4Subnets[0].Subnet
 	gatewayIP := cr.Spec.Ipv4Subnets[0].Gateway
 	excludeIps := cr.Spec.Ipv4Subnets[0].ExcludeIps
-	_, err := createOvnLS(name, subnet, gatewayIP, excludeIps)
+	_, err = createOvnLS(name, subnet, gatewayIP, excludeIps)
 	if err != nil {
 		return err
 	}
 
-	// Add localnet port.
+	// Add localnet port, and claim the switch for the provider network
 	stdout, stderr, err = RunOVNNbctl("--wait=hv", "--", "--may-exist", "lsp-add", name, "server-localnet_"+name, "--",
 		"lsp-set-addresses", "server-localnet_"+name, "unknown", "--",
 		"lsp-set-type", "server-localnet_"+name, "localnet", "--",
-		"lsp-set-options", "server-localnet_"+name, "network_name=nw_"+name)
+		"lsp-set-options", "server-localnet_"+name, "network_name=nw_"+name, "--",
+		"set", "logical_switch", name,
+		fmt.Sprintf("external_ids:%s=%q", providerNetworkExternalID, providerNetworkOwner(cr.Namespace, cr.Name)))
 	if err != nil {
 		log.Error(err, "Failed to add logical port to switch", "stderr", stderr, "stdout", stdout)
 		return err
@@ -310,7 +443,10 @@ func (oc *Controller) CreateProviderNetwork(cr *k8sv1alpha1.ProviderNetwork) err
 // DeleteProviderNetwork in OVN controller
 func (oc *Controller) DeleteProviderNetwork(cr *k8sv1alpha1.ProviderNetwork) error {
 
-	name := cr.Name
+	name, err := ProviderNetworkSwitch(cr.Namespace, cr.Name)
+	if err != nil {
+		return err
+	}
 	stdout, stderr, err := RunOVNNbctl("--if-exist", "--wait=hv", "ls-del", name)
 	if err != nil {
 		log.Error(err, "Failed to delete switch", "name", name, "stdout", stdout, "stderr", stderr)
@@ -466,7 +602,7 @@ func (oc *Controller) getNodeLogicalPortIPAddr(pod *kapi.Pod) (ipAddress string,
 	return ipAddr, nil
 }
 
-func (oc *Controller) addLogicalPortWithSwitch(pod *kapi.Pod, logicalSwitch, ipAddress, macAddress, gwipAddress, portName string) (annotation string) {
+func (oc *Controller) addLogicalPortWithSwitch(pod *kapi.Pod, logicalSwitch, ipAddress, macAddress, gwipAddress, portName string) (annotation *podnetwork.InterfaceResult) {
 	var out, stderr string
 	var err error
 	var isStaticIP bool
@@ -564,7 +700,11 @@ func (oc *Controller) addLogicalPortWithSwitch(pod *kapi.Pod, logicalSwitch, ipA
 		}
 	}
 
-	annotation = fmt.Sprintf(`{\"ip_address\":\"%s/%s\", \"mac_address\":\"%s\", \"gateway_ip\": \"%s\"}`, addresses[1], mask, addresses[0], gatewayIP)
+	annotation = &podnetwork.InterfaceResult{
+		IPAddress:  fmt.Sprintf("%s/%s", addresses[1], mask),
+		MacAddress: addresses[0],
+		GatewayIP:  gatewayIP,
+	}
 
 	return annotation
 }
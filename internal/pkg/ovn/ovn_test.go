@@ -0,0 +1,155 @@
+package ovn
+
+import (
+	"testing"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
+	ovntest "ovn4nfv-k8s-plugin/internal/pkg/testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeexec "k8s.io/utils/exec/testing"
+)
+
+func TestOvn(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OVN Test Suite")
+}
+
+// newFakeExec returns an exec running the commands in order
+func newFakeExec(cmds []string, outputs map[string]string) *fakeexec.FakeExec {
+	fexec := &fakeexec.FakeExec{
+		LookPathFunc: func(file string) (string, error) {
+			return "/fake-bin/" + file, nil
+		},
+	}
+	for _, cmd := range cmds {
+		fexec.CommandScript = ovntest.AddFakeCmd(fexec.CommandScript, &ovntest.ExpectedCmd{
+			Cmd:    "ovn-nbctl --timeout=15 " + cmd,
+			Output: outputs[cmd],
+		})
+	}
+	return fexec
+}
+
+var _ = Describe("Logical ports", func() {
+	var pod *kapi.Pod
+
+	BeforeEach(func() {
+		annotation, err := podnetwork.MarshalInterfaces([]podnetwork.InterfaceResult{
+			{IPAddress: "172.16.44.2/24", MacAddress: "0a:00:00:00:00:02", GatewayIP: "172.16.44.1",
+				DefaultGateway: "false", Interface: "net1"},
+			{IPAddress: "10.233.64.5/18", MacAddress: "0a:00:00:00:00:01", GatewayIP: "10.233.64.1",
+				DefaultGateway: "false", Interface: "*"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		pod = &kapi.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "pod",
+			Annotations: map[string]string{Ovn4nfvAnnotationTag: annotation},
+		}}
+	})
+
+	It("adds the requested interfaces and deletes the others", func() {
+		cmds := []string{
+			"--data=bare --no-heading --columns=name find logical_switch name=ovn-port-net",
+			"--if-exists get logical_switch_port default_pod_net1 external_ids:logical_switch",
+			"--if-exists lsp-del default_pod_net1",
+			"--if-exists get logical_switch_port default_pod external_ids:logical_switch",
+			"--may-exist lsp-add ovn-port-net default_pod_net2 -- lsp-set-addresses default_pod_net2 0a:00:00:00:00:05 172.16.33.5 " +
+				"-- --if-exists clear logical_switch_port default_pod_net2 dynamic_addresses -- set logical_switch_port default_pod_net2 " +
+				"external-ids:namespace=default external-ids:logical_switch=ovn-port-net external-ids:pod=true",
+			"get logical_switch_port default_pod_net2 addresses",
+			"--if-exists get logical_switch ovn-port-net external_ids:gateway_ip",
+		}
+		fexec := newFakeExec(cmds, map[string]string{
+			cmds[0]: "ovn-port-net",
+			cmds[1]: "ovn-priv-net",
+			cmds[3]: Ovn4nfvDefaultNw,
+			cmds[5]: `["0a:00:00:00:00:05 172.16.33.5"]`,
+			cmds[6]: "172.16.33.1/24",
+		})
+		Expect(SetExec(fexec)).To(Succeed())
+		oc := &Controller{gatewayCache: make(map[string]string)}
+
+		annotations, added, removed, err := oc.UpdateLogicalPorts(pod, []podnetwork.InterfaceRequest{{
+			Name:        "ovn-port-net",
+			Interface:   "net2",
+			IPAddress:   "172.16.33.5",
+			MacAddress:  "0a:00:00:00:00:05",
+			GWIPAddress: "172.16.33.1",
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fexec.CommandCalls).To(Equal(len(cmds)))
+		Expect(removed).To(Equal([]string{"net1"}))
+		Expect(added).To(Equal([]podnetwork.InterfaceResult{{IPAddress: "172.16.33.5/24", MacAddress: "0a:00:00:00:00:05",
+			GatewayIP: "172.16.33.1", DefaultGateway: "false", Interface: "net2"}}))
+
+		interfaces, err := podnetwork.ParseInterfaces(annotations[Ovn4nfvAnnotationTag])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(interfaces).To(HaveLen(2))
+		Expect(interfaces[0].Interface).To(Equal("*"))
+		Expect(interfaces[1].Interface).To(Equal("net2"))
+		Expect(annotations[podnetwork.NetworkStatusAnnotation]).To(MatchJSON(`[
+			{"name": "ovn4nfvk8s-default-nw", "interface": "eth0", "ips": ["10.233.64.5"], "mac": "0a:00:00:00:00:01", "default": true},
+			{"name": "ovn-port-net", "interface": "net2", "ips": ["172.16.33.5"], "mac": "0a:00:00:00:00:05"}]`))
+	})
+
+	It("leaves the interfaces still requested alone", func() {
+		cmds := []string{
+			"--data=bare --no-heading --columns=name find logical_switch name=ovn-priv-net",
+			"--if-exists get logical_switch_port default_pod_net1 external_ids:logical_switch",
+			"--if-exists get logical_switch_port default_pod external_ids:logical_switch",
+		}
+		fexec := newFakeExec(cmds, map[string]string{
+			cmds[0]: "ovn-priv-net",
+			cmds[1]: "ovn-priv-net",
+			cmds[2]: Ovn4nfvDefaultNw,
+		})
+		Expect(SetExec(fexec)).To(Succeed())
+		oc := &Controller{gatewayCache: make(map[string]string)}
+
+		_, added, removed, err := oc.UpdateLogicalPorts(pod, []podnetwork.InterfaceRequest{{Name: "ovn-priv-net", Interface: "net1"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fexec.CommandCalls).To(Equal(len(cmds)))
+		Expect(added).To(BeEmpty())
+		Expect(removed).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Provider network switches", func() {
+	const (
+		findNew    = "--data=bare --no-heading --columns=name find logical_switch_port name=server-localnet_foo_pnet"
+		findLegacy = "--data=bare --no-heading --columns=name find logical_switch_port name=server-localnet_pnet"
+		getOwner   = "--if-exists get logical_switch pnet external_ids:provider_network"
+	)
+
+	lookup := func(namespace string, cmds []string, outputs map[string]string) string {
+		fexec := newFakeExec(cmds, outputs)
+		Expect(SetExec(fexec)).To(Succeed())
+		lsName, err := ProviderNetworkSwitch(namespace, "pnet")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fexec.CommandCalls).To(Equal(len(cmds)))
+		return lsName
+	}
+
+	It("names the switches after the namespace and the provider network", func() {
+		Expect(lookup("default", nil, nil)).To(Equal("pnet"))
+		Expect(lookup("foo", []string{findNew, findLegacy}, nil)).To(Equal("foo_pnet"))
+		Expect(lookup("foo", []string{findNew}, map[string]string{findNew: "server-localnet_foo_pnet"})).To(Equal("foo_pnet"))
+	})
+
+	It("keeps the switches named after the provider network only", func() {
+		Expect(lookup("foo", []string{findNew, findLegacy, getOwner},
+			map[string]string{findLegacy: "server-localnet_pnet"})).To(Equal("pnet"))
+		Expect(lookup("foo", []string{findNew, findLegacy, getOwner},
+			map[string]string{findLegacy: "server-localnet_pnet", getOwner: "foo/pnet"})).To(Equal("pnet"))
+	})
+
+	It("leaves the switches of other provider networks alone", func() {
+		Expect(lookup("foo", []string{findNew, findLegacy, getOwner},
+			map[string]string{findLegacy: "server-localnet_pnet", getOwner: "default/pnet"})).To(Equal("foo_pnet"))
+	})
+})
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ovn
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// chainPolicyPriority is above the default routing of the cluster router
+	chainPolicyPriority = 2000
+	chainExternalID     = "ovn4nfv-chain"
+)
+
+// RouterPolicy reroutes the packets matching Match to one of Nexthops
+type RouterPolicy struct {
+	Match    string
+	Nexthops []string
+}
+
+// RouterPortName returns the port of the cluster router on a network
+func RouterPortName(network string) string {
+	return "rtos-" + network
+}
+
+// SetChainPolicies replaces the policies of the chain on the cluster router
+// in a single transaction. Multiple nexthops need OVN 20.12 or later.
+func SetChainPolicies(chain string, policies []RouterPolicy) error {
+	args, err := removeChainPoliciesArgs(chain)
+	if err != nil {
+		return err
+	}
+	for i, p := range policies {
+		id := fmt.Sprintf("@p%d", i)
+		var nexthops []string
+		for _, nh := range p.Nexthops {
+			nexthops = append(nexthops, fmt.Sprintf("%q", nh))
+		}
+		args = append(args, "--", "--id="+id, "create", "logical_router_policy",
+			fmt.Sprintf("priority=%d", chainPolicyPriority),
+			fmt.Sprintf("match=%q", p.Match),
+			"action=reroute",
+			"nexthops="+strings.Join(nexthops, ","),
+			fmt.Sprintf("external_ids:%s=%q", chainExternalID, chain),
+			"--", "add", "logical_router", ovn4nfvRouterName, "policies", id)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	stdout, stderr, err := RunOVNNbctl(args...)
+	if err != nil {
+		log.Error(err, "Failed to set chain policies", "chain", chain, "stdout", stdout, "stderr", stderr)
+		return err
+	}
+	return nil
+}
+
+// DeleteChainPolicies removes the policies of the chain from the cluster router
+func DeleteChainPolicies(chain string) error {
+	return SetChainPolicies(chain, nil)
+}
+
+// removeChainPoliciesArgs returns the ovn-nbctl arguments removing the
+// policies of the chain
+func removeChainPoliciesArgs(chain string) ([]string, error) {
+	stdout, stderr, err := RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid",
+		"find", "logical_router_policy", fmt.Sprintf("external_ids:%s=%q", chainExternalID, chain))
+	if err != nil {
+		log.Error(err, "Failed to find chain policies", "chain", chain, "stderr", stderr)
+		return nil, err
+	}
+	var args []string
+	for _, uuid := range strings.Fields(stdout) {
+		args = append(args, "--", "remove", "logical_router", ovn4nfvRouterName, "policies", uuid)
+	}
+	return args, nil
+}
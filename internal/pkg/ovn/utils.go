@@ -5,6 +5,8 @@ import (
 	"fmt"
 	kexec "k8s.io/utils/exec"
 	"os"
+	"ovn4nfv-k8s-plugin/internal/pkg/metrics"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
@@ -90,6 +92,7 @@ func runOVNretry(cmdPath string, args ...string) (*bytes.Buffer, *bytes.Buffer,
 				return stdout, stderr, err
 			}
 			retriesLeft--
+			metrics.CommandRetries.WithLabelValues(filepath.Base(cmdPath)).Inc()
 			time.Sleep(2 * time.Second)
 		} else {
 			// Some other problem for caller to handle
@@ -105,7 +108,9 @@ func run(cmdPath string, args ...string) (*bytes.Buffer, *bytes.Buffer, error) {
 	cmd.SetStdout(stdout)
 	cmd.SetStderr(stderr)
 	log.V(1).Info("exec:", "cmdPath", cmdPath, "args", strings.Join(args, " "))
+	start := time.Now()
 	err := cmd.Run()
+	metrics.ObserveCommand(cmdPath, start, err)
 	if err != nil {
 		log.Info("ovs", "Error:", err, "cmdPath", cmdPath, "args", strings.Join(args, " "), "stdout", stdout, "stderr", stderr)
 	} else {
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package podnetwork
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	// NetworksAnnotation is the network selection annotation of the Network
+	// Plumbing Working Group specification
+	NetworksAnnotation = "k8s.v1.cni.cncf.io/networks"
+	// NetworkStatusAnnotation is the network status annotation of the
+	// Network Plumbing Working Group specification
+	NetworkStatusAnnotation = "k8s.v1.cni.cncf.io/network-status"
+)
+
+// NetworkSelectionElement is an element of the networks annotation
+type NetworkSelectionElement struct {
+	// Name of the NetworkAttachmentDefinition
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// IPRequest are the static addresses, with or without prefix length
+	IPRequest        []string `json:"ips,omitempty"`
+	MacRequest       string   `json:"mac,omitempty"`
+	InterfaceRequest string   `json:"interface,omitempty"`
+	// GatewayRequest asks for the default route through the interface
+	GatewayRequest []string `json:"default-route,omitempty"`
+}
+
+// NetworkStatus is an element of the network-status annotation
+type NetworkStatus struct {
+	Name      string   `json:"name"`
+	Interface string   `json:"interface,omitempty"`
+	IPs       []string `json:"ips,omitempty"`
+	Mac       string   `json:"mac,omitempty"`
+	Default   bool     `json:"default,omitempty"`
+}
+
+// ParseNetworkSelection decodes the networks annotation, either a JSON list
+// of elements or a comma separated list of [namespace/]name[@interface]
+func ParseNetworkSelection(annotation string) ([]NetworkSelectionElement, error) {
+	annotation = strings.TrimSpace(annotation)
+	if annotation == "" {
+		return nil, nil
+	}
+	var elements []NetworkSelectionElement
+	if strings.HasPrefix(annotation, "[") {
+		if err := json.Unmarshal([]byte(annotation), &elements); err != nil {
+			return nil, fmt.Errorf("failed to decode %s annotation: %v", NetworksAnnotation, err)
+		}
+	} else {
+		for _, item := range strings.Split(annotation, ",") {
+			element := NetworkSelectionElement{}
+			item = strings.TrimSpace(item)
+			if i := strings.Index(item, "@"); i >= 0 {
+				element.InterfaceRequest = item[i+1:]
+				item = item[:i]
+			}
+			if i := strings.Index(item, "/"); i >= 0 {
+				element.Namespace = item[:i]
+				item = item[i+1:]
+			}
+			element.Name = item
+			elements = append(elements, element)
+		}
+	}
+	for i, element := range elements {
+		if element.Name == "" {
+			return nil, fmt.Errorf("%s annotation: element %d has no name", NetworksAnnotation, i)
+		}
+		if len(element.IPRequest) > 1 {
+			return nil, fmt.Errorf("%s annotation: element %d requests more than one IP address", NetworksAnnotation, i)
+		}
+	}
+	return elements, nil
+}
+
+// ToInterfaceRequest returns the interface requested by the element at index
+// of the networks annotation. Interfaces are named net1, net2... by default
+// as Multus does.
+func (e NetworkSelectionElement) ToInterfaceRequest(index int) (InterfaceRequest, error) {
+	request := InterfaceRequest{
+		Name:       e.Name,
+		Namespace:  e.Namespace,
+		Interface:  e.InterfaceRequest,
+		MacAddress: e.MacRequest,
+	}
+	if request.Interface == "" {
+		request.Interface = fmt.Sprintf("net%d", index+1)
+	}
+	if len(e.IPRequest) > 0 {
+		ip := e.IPRequest[0]
+		if addr, _, err := net.ParseCIDR(ip); err == nil {
+			ip = addr.String()
+		}
+		if net.ParseIP(ip) == nil {
+			return request, fmt.Errorf("invalid IP address %q for network %s", e.IPRequest[0], e.Name)
+		}
+		request.IPAddress = ip
+	}
+	if len(e.GatewayRequest) > 0 {
+		request.DefaultGateway = "true"
+		if net.ParseIP(e.GatewayRequest[0]) == nil {
+			return request, fmt.Errorf("invalid default route %q for network %s", e.GatewayRequest[0], e.Name)
+		}
+		request.GWIPAddress = e.GatewayRequest[0]
+	}
+	return request, nil
+}
+
+// NetworkStatuses returns the network-status of the interfaces, networks
+// are the names of their networks. The default route goes through the first
+// interface asking for it, else through the primary interface, as the CNI
+// server sets it up. The status lists the interfaces allocated to the pod,
+// it is published before the CNI plumbs them.
+func NetworkStatuses(networks []string, interfaces []InterfaceResult) []NetworkStatus {
+	defaultIntf := DefaultInterface
+	for _, intf := range interfaces {
+		if intf.Interface != DefaultInterface && intf.DefaultGateway == "true" {
+			defaultIntf = intf.Interface
+			break
+		}
+	}
+	var statuses []NetworkStatus
+	for i, intf := range interfaces {
+		status := NetworkStatus{
+			Name:      networks[i],
+			Interface: intf.Interface,
+			Mac:       intf.MacAddress,
+			Default:   intf.Interface == defaultIntf,
+		}
+		if intf.Interface == DefaultInterface {
+			status.Interface = "eth0"
+		}
+		if addr, _, err := net.ParseCIDR(intf.IPAddress); err == nil {
+			status.IPs = []string{addr.String()}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// MarshalNetworkStatus encodes the network-status annotation
+func MarshalNetworkStatus(statuses []NetworkStatus) (string, error) {
+	b, err := json.Marshal(statuses)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,48 @@
+package podnetwork
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Network selection", func() {
+	It("parses the comma separated list", func() {
+		elements, err := ParseNetworkSelection("ovn-priv-net, test/ovn-port-net@net5")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(elements).To(Equal([]NetworkSelectionElement{
+			{Name: "ovn-priv-net"},
+			{Name: "ovn-port-net", Namespace: "test", InterfaceRequest: "net5"},
+		}))
+	})
+
+	It("maps the JSON elements to interface requests", func() {
+		elements, err := ParseNetworkSelection(`[{"name": "ovn-priv-net", "ips": ["172.16.44.5/24"], "mac": "0a:00:00:00:00:3c",
+			"default-route": ["172.16.44.1"]}, {"name": "ovn-port-net", "interface": "net5"}]`)
+		Expect(err).NotTo(HaveOccurred())
+		intf, err := elements[0].ToInterfaceRequest(0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(intf).To(Equal(InterfaceRequest{Name: "ovn-priv-net", Interface: "net1", IPAddress: "172.16.44.5",
+			MacAddress: "0a:00:00:00:00:3c", DefaultGateway: "true", GWIPAddress: "172.16.44.1"}))
+		intf, err = elements[1].ToInterfaceRequest(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(intf.Interface).To(Equal("net5"))
+	})
+
+	It("rejects elements it can't apply", func() {
+		_, err := ParseNetworkSelection(`[{"name": "ovn-priv-net", "ips": ["172.16.44.5", "172.16.44.6"]}]`)
+		Expect(err).To(MatchError(ContainSubstring("more than one IP address")))
+		_, err = ParseNetworkSelection(`test/@net1`)
+		Expect(err).To(MatchError(ContainSubstring("has no name")))
+	})
+
+	It("reports the status of the interfaces", func() {
+		statuses := NetworkStatuses([]string{"test/ovn-port-net", "ovnk8s-default-nw"}, []InterfaceResult{
+			{IPAddress: "172.16.33.5/24", MacAddress: "0a:00:00:00:00:3c", Interface: "net1", DefaultGateway: "false"},
+			{IPAddress: "10.233.64.5/18", MacAddress: "0a:00:00:00:00:3d", Interface: DefaultInterface},
+		})
+		Expect(statuses).To(Equal([]NetworkStatus{
+			{Name: "test/ovn-port-net", Interface: "net1", IPs: []string{"172.16.33.5"}, Mac: "0a:00:00:00:00:3c"},
+			{Name: "ovnk8s-default-nw", Interface: "eth0", IPs: []string{"10.233.64.5"}, Mac: "0a:00:00:00:00:3d", Default: true},
+		}))
+	})
+})
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package podnetwork holds the pod annotations exchanged by the users, the
+// nfn-operator and the CNI server. The nfn-network annotation asks for the
+// interfaces of the pod, the nfn-operator answers with the ovnInterfaces
+// annotation the CNI server plumbs.
+//
+// Fields are only added to the annotations, and the readers ignore the
+// fields they don't know. The version of the annotations written by a
+// release tells the readers which fields to expect, annotations without a
+// version were written by releases before the typed schema.
+package podnetwork
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// NetworkAnnotation lists the networks requested by the pod
+	NetworkAnnotation = "k8s.plugin.opnfv.org/nfn-network"
+	// InterfacesAnnotation lists the interfaces the nfn-operator created for
+	// the pod
+	InterfacesAnnotation = "k8s.plugin.opnfv.org/ovnInterfaces"
+	// RoutesAnnotation lists additional routes of the pod
+	RoutesAnnotation = "ovnNetworkRoutes"
+
+	// Version is the version of the annotations written by this release
+	Version = "1"
+	// OvnType is the networking type of the nfn-network annotation
+	OvnType = "ovn4nfv"
+	// DefaultInterface is the interface name of the primary interface in
+	// the ovnInterfaces annotation
+	DefaultInterface = "*"
+)
+
+// NetworkRequest is the nfn-network annotation. Field names are matched
+// case-insensitively.
+type NetworkRequest struct {
+	Version   string             `json:"version,omitempty"`
+	Type      string             `json:"type"`
+	Interface []InterfaceRequest `json:"interface"`
+}
+
+// InterfaceRequest is an interface requested by the pod
+type InterfaceRequest struct {
+	// Name of the Network or ProviderNetwork
+	Name string `json:"name"`
+	// Namespace of the ProviderNetwork, the pod namespace by default
+	Namespace string `json:"namespace,omitempty"`
+	// Interface is the name of the interface in the pod
+	Interface      string `json:"interface,omitempty"`
+	NetType        string `json:"netType,omitempty"`
+	DefaultGateway string `json:"defaultGateway,omitempty"`
+	// IPAddress and MacAddress are static addresses of the interface
+	IPAddress  string `json:"ipAddress,omitempty"`
+	MacAddress string `json:"macAddress,omitempty"`
+	// GWIPAddress overrides the gateway of the interface
+	GWIPAddress string `json:"gwIpAddress,omitempty"`
+	// NetAttachDef is the namespace/name of the NetworkAttachmentDefinition
+	// selecting the network, Multus then plumbs the interface through it
+	NetAttachDef string `json:"-"`
+}
+
+// InterfaceResult is an interface of the ovnInterfaces annotation. The
+// annotation stays a list of string fields, which the agents before the
+// typed schema read.
+type InterfaceResult struct {
+	Version string `json:"version,omitempty"`
+	// IPAddress is the address of the interface in CIDR notation
+	IPAddress      string `json:"ip_address"`
+	MacAddress     string `json:"mac_address"`
+	GatewayIP      string `json:"gateway_ip"`
+	DefaultGateway string `json:"defaultGateway,omitempty"`
+	// Interface is the name of the interface in the pod, DefaultInterface
+	// for the primary interface
+	Interface string `json:"interface"`
+	// Network is set on the interfaces plumbed by the CNI call of a
+	// NetworkAttachmentDefinition of the network, the primary call skips them
+	Network string `json:"network,omitempty"`
+}
+
+// Route is a route of the ovnNetworkRoutes annotation
+type Route struct {
+	Dst string `json:"dst"`
+	GW  string `json:"gw"`
+	Dev string `json:"dev"`
+}
+
+// ParseNetworkRequest decodes the nfn-network annotation
+func ParseNetworkRequest(annotation string) (*NetworkRequest, error) {
+	request := &NetworkRequest{}
+	if err := json.Unmarshal([]byte(annotation), request); err != nil {
+		return nil, fmt.Errorf("failed to decode %s annotation: %v", NetworkAnnotation, err)
+	}
+	if err := checkVersion(request.Version); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ParseInterfaces decodes the ovnInterfaces annotation
+func ParseInterfaces(annotation string) ([]InterfaceResult, error) {
+	if annotation == "" {
+		return nil, fmt.Errorf("empty %s annotation", InterfacesAnnotation)
+	}
+	var interfaces []InterfaceResult
+	if err := json.Unmarshal([]byte(annotation), &interfaces); err != nil {
+		return nil, fmt.Errorf("failed to decode %s annotation: %v", InterfacesAnnotation, err)
+	}
+	for _, intf := range interfaces {
+		if err := checkVersion(intf.Version); err != nil {
+			return nil, err
+		}
+	}
+	return interfaces, nil
+}
+
+// MarshalInterfaces encodes the ovnInterfaces annotation in the current
+// version
+func MarshalInterfaces(interfaces []InterfaceResult) (string, error) {
+	for i := range interfaces {
+		interfaces[i].Version = Version
+	}
+	b, err := json.Marshal(interfaces)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ParseRoutes decodes the ovnNetworkRoutes annotation
+func ParseRoutes(annotation string) ([]Route, error) {
+	var routes []Route
+	if err := json.Unmarshal([]byte(annotation), &routes); err != nil {
+		return nil, fmt.Errorf("failed to decode %s annotation: %v", RoutesAnnotation, err)
+	}
+	return routes, nil
+}
+
+// checkVersion accepts the annotations of this and of the former releases,
+// and the newer ones, whose additional fields are ignored
+func checkVersion(version string) error {
+	if version == "" {
+		return nil
+	}
+	if v, err := strconv.Atoi(version); err != nil || v < 1 {
+		return fmt.Errorf("invalid annotation version %q", version)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package podnetwork
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPodNetwork(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pod Network Test Suite")
+}
+
+var _ = Describe("Pod annotations", func() {
+	It("decodes the request fields case-insensitively", func() {
+		request, err := ParseNetworkRequest(`{"type": "ovn4nfv", "interface": [{"name": "left-pnetwork", "interface": "net0",
+			"defaultgateway": "true", "gwipaddress": "172.30.10.3"}]}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(request.Interface).To(Equal([]InterfaceRequest{{Name: "left-pnetwork", Interface: "net0",
+			DefaultGateway: "true", GWIPAddress: "172.30.10.3"}}))
+	})
+
+	It("reads the interfaces written before the typed schema", func() {
+		interfaces, err := ParseInterfaces(`[{"ip_address":"10.154.142.5/18", "mac_address":"0a:00:00:00:00:3c", "gateway_ip": "10.154.142.1","defaultGateway":"false","interface":"net0"}]`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(interfaces).To(Equal([]InterfaceResult{{IPAddress: "10.154.142.5/18", MacAddress: "0a:00:00:00:00:3c",
+			GatewayIP: "10.154.142.1", DefaultGateway: "false", Interface: "net0"}}))
+	})
+
+	It("ignores the fields of newer versions", func() {
+		interfaces, err := ParseInterfaces(`[{"version":"2","ip_address":"10.154.142.5/18","mac_address":"0a:00:00:00:00:3c","gateway_ip":"10.154.142.1","interface":"*","mtu":9000}]`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(interfaces[0].Interface).To(Equal(DefaultInterface))
+		_, err = ParseInterfaces(`[{"version":"v2"}]`)
+		Expect(err).To(MatchError(ContainSubstring("invalid annotation version")))
+	})
+
+	It("writes interfaces the agents before the typed schema read", func() {
+		value, err := MarshalInterfaces([]InterfaceResult{{IPAddress: "10.154.142.5/18", MacAddress: "0a:00:00:00:00:3c",
+			GatewayIP: "10.154.142.1", Interface: DefaultInterface}})
+		Expect(err).NotTo(HaveOccurred())
+		var legacy []map[string]string
+		Expect(json.Unmarshal([]byte(value), &legacy)).To(Succeed())
+		Expect(legacy[0]).To(HaveKeyWithValue("version", Version))
+		Expect(legacy[0]).To(HaveKeyWithValue("ip_address", "10.154.142.5/18"))
+		Expect(legacy[0]).To(HaveKeyWithValue("interface", "*"))
+	})
+})
@@ -21,223 +21,760 @@ import (
 	"fmt"
 	"ovn4nfv-k8s-plugin/internal/pkg/network"
 	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
-	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+	k8sv1alpha2 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha2"
+	"sort"
 	"strings"
 
 	pb "ovn4nfv-k8s-plugin/internal/pkg/nfnNotify/proto"
 
 	"github.com/containernetworking/plugins/pkg/ns"
-	"github.com/docker/docker/client"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
 
 var log = logf.Log.WithName("chaining")
 
 type RoutingInfo struct {
-	Name                 string            // Name of the pod
-	Namespace            string            // Namespace of the Pod
-	Id                   string            // Container ID for pod
-	Node                 string            // Hostname where Pod is scheduled
-	LeftNetworkRoute     k8sv1alpha1.Route // TODO: Update to support multiple networks
-	RightNetworkRoute    k8sv1alpha1.Route // TODO: Update to support multiple networks
-	DynamicNetworkRoutes []k8sv1alpha1.Route
+	Name                 string              // Name of the pod
+	Namespace            string              // Namespace of the Pod
+	Id                   string              // Container ID for pod
+	Node                 string              // Hostname where Pod is scheduled
+	LeftNetworkRoutes    []k8sv1alpha2.Route // Routes to the left networks, one per ready pod of the previous hop
+	RightNetworkRoutes   []k8sv1alpha2.Route // Routes to the right networks, one per ready pod of the next hop
+	DynamicNetworkRoutes []k8sv1alpha2.Route
+	LeaveChain           bool // Pod leaves the chain, set on the routes to be removed
 }
 
-var chainRoutingInfo []RoutingInfo
+// Routes returns all the routes to be installed in the pod
+func (r RoutingInfo) Routes() []k8sv1alpha2.Route {
+	var routes []k8sv1alpha2.Route
+	routes = append(routes, r.LeftNetworkRoutes...)
+	routes = append(routes, r.RightNetworkRoutes...)
+	return append(routes, r.DynamicNetworkRoutes...)
+}
 
-// Calcuate route to get to left and right edge networks and other networks (not adjacent) in the chain
-func calculateDeploymentRoutes(namespace, label string, pos int, num int, ln []k8sv1alpha1.RoutingNetwork, rn []k8sv1alpha1.RoutingNetwork, networkList, deploymentList []string) (r RoutingInfo, err error) {
+// RouteData returns the routes of the pod in the messages to the agents
+func (r RoutingInfo) RouteData() []*pb.RouteData {
+	var routes []*pb.RouteData
+	for _, rt := range r.Routes() {
+		routes = append(routes, &pb.RouteData{
+			Dst: rt.Dst,
+			Gw:  rt.GW,
+		})
+	}
+	return routes
+}
 
-	var nextLeftIP string
-	var nextRightIP string
+// ParseNetworkChain returns the pod selectors of the hops and the networks
+// between them. The egress network of a hop must be the ingress network of
+// the next hop.
+func ParseNetworkChain(spec k8sv1alpha2.RouteSpec) (selectors []labels.Selector, networkList []string, err error) {
+	hops := spec.Hops
+	if len(hops) == 0 {
+		return nil, nil, fmt.Errorf("Network chain has no hops")
+	}
+	for i, hop := range hops {
+		selector, err := metav1.LabelSelectorAsSelector(&hop.PodSelector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid pod selector of hop %d: %v", i, err)
+		}
+		if selector.Empty() {
+			return nil, nil, fmt.Errorf("Pod selector of hop %d selects all the pods", i)
+		}
+		selectors = append(selectors, selector)
+		if i == 0 && hop.IngressNetwork != "" {
+			return nil, nil, fmt.Errorf("First hop is attached to the left networks, ingress network %s not expected", hop.IngressNetwork)
+		}
+		if i == len(hops)-1 {
+			if hop.EgressNetwork != "" {
+				return nil, nil, fmt.Errorf("Last hop is attached to the right networks, egress network %s not expected", hop.EgressNetwork)
+			}
+			break
+		}
+		if hop.EgressNetwork == "" || hop.EgressNetwork != hops[i+1].IngressNetwork {
+			return nil, nil, fmt.Errorf("Egress network of hop %d must be the ingress network of hop %d", i, i+1)
+		}
+		networkList = append(networkList, hop.EgressNetwork)
+	}
+	return selectors, networkList, nil
+}
 
-	r.Namespace = namespace
-	// Get a config to talk to the apiserver
-	cfg, err := config.GetConfig()
+// hopPods returns the ready pods of a hop of the chain. Traffic is
+// load-balanced across all of them.
+func hopPods(c client.Client, namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	err := c.List(context.TODO(), pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector})
 	if err != nil {
-		return RoutingInfo{}, err
+		log.Error(err, "Deloyment with label not found", "label", selector.String())
+		return nil, err
 	}
-	var k *kubernetes.Clientset
-	k, err = kubernetes.NewForConfig(cfg)
-	if err != nil {
-		log.Error(err, "Error building Kuberenetes clientset")
-		return RoutingInfo{}, err
+	var ready []*corev1.Pod
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		if p.Status.Phase != corev1.PodRunning || !p.DeletionTimestamp.IsZero() || !podReady(p) {
+			continue
+		}
+		ready = append(ready, p)
+	}
+	if len(ready) == 0 {
+		return nil, fmt.Errorf("No ready pod with label %s", selector.String())
+	}
+	// Keep the nexthop order stable between reconciles
+	sort.Slice(ready, func(i, j int) bool { return ready[i].Name < ready[j].Name })
+	return ready, nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podIP returns the IP address of the pod on the network
+var podIP = func(network string, pod *corev1.Pod) (string, error) {
+	// Port names are <namespace>_<pod name>_<interface>
+	return ovn.GetIPAdressForPod(network, pod.Namespace+"_"+pod.Name+"_")
+}
+
+// networkGateway returns the address of the cluster router on the network
+var networkGateway = ovn.GetNetworkGateway
+
+// chainState caches the lookups done in OVN while calculating the routes
+// of a chain
+type chainState struct {
+	hops      [][]*corev1.Pod
+	ips       map[string]string
+	subnets   map[string]string
+	routerIPs map[string]string
+}
+
+// gateways returns the IP addresses on the network of the pods of a hop.
+// Pods without an address on the network yet are left out.
+func (cs *chainState) gateways(network string, hop int) []string {
+	var gws []string
+	for _, pod := range cs.hops[hop] {
+		if ip := cs.ip(network, pod); ip != "" {
+			gws = append(gws, ip)
+		}
+	}
+	return gws
+}
+
+// edgeNetwork returns the first edge network the pod is attached to, empty
+// when the pod has no address on any of them yet
+func (cs *chainState) edgeNetwork(networks []k8sv1alpha2.RoutingNetwork, pod *corev1.Pod) string {
+	for _, n := range networks {
+		if cs.ip(n.NetworkName, pod) != "" {
+			return n.NetworkName
+		}
+	}
+	return ""
+}
+
+// ip returns the IP address of the pod on the network, empty when the pod
+// has no address on the network yet
+func (cs *chainState) ip(network string, pod *corev1.Pod) string {
+	key := network + "/" + pod.Namespace + "/" + pod.Name
+	ip, ok := cs.ips[key]
+	if !ok {
+		var err error
+		ip, err = podIP(network, pod)
+		if err != nil {
+			log.V(1).Info("No address for pod", "network", network, "pod", pod.Name, "error", err.Error())
+		}
+		cs.ips[key] = ip
+	}
+	return ip
+}
+
+func (cs *chainState) subnet(network string) (string, error) {
+	if subnet, ok := cs.subnets[network]; ok {
+		return subnet, nil
 	}
-	lo := v1.ListOptions{LabelSelector: label}
-	// List the Pods matching the Labels
-	pods, err := k.CoreV1().Pods(namespace).List(lo)
+	subnet, err := ovn.GetNetworkSubnet(network)
 	if err != nil {
-		log.Error(err, "Deloyment with label not found", "label", label)
-		return RoutingInfo{}, err
-	}
-	// LOADBALANCER NOT YET SUPPORTED - Assuming deployment has only one Pod
-	if len(pods.Items) <= 0 {
-		log.Error(err, "Deloyment with label not found", "label", label)
-		return RoutingInfo{}, fmt.Errorf("Pod not found")
-	}
-	// Get the containerID of the first container
-	r.Id = strings.TrimPrefix(pods.Items[0].Status.ContainerStatuses[0].ContainerID, "docker://")
-	r.Name = pods.Items[0].GetName()
-	r.Node = pods.Items[0].Spec.NodeName
+		return "", err
+	}
+	cs.subnets[network] = subnet
+	return subnet, nil
+}
+
+// routerRoutes returns the routes to the subnets of the edge networks
+// through the cluster router on the network. The subnet of the network
+// itself is left to the connected route of the pod.
+func (cs *chainState) routerRoutes(network string, edges []k8sv1alpha2.RoutingNetwork) ([]k8sv1alpha2.Route, error) {
+	gw, ok := cs.routerIPs[network]
+	if !ok {
+		var err error
+		gw, err = networkGateway(network)
+		if err != nil {
+			return nil, err
+		}
+		cs.routerIPs[network] = gw
+	}
+	var routes []k8sv1alpha2.Route
+	for _, n := range edges {
+		if n.NetworkName != network {
+			routes = append(routes, k8sv1alpha2.Route{Dst: n.Subnet, GW: gw})
+		}
+	}
+	return routes, nil
+}
+
+// multipathRoutes returns one route to dst per gateway
+func multipathRoutes(dst string, gws []string) []k8sv1alpha2.Route {
+	var routes []k8sv1alpha2.Route
+	for _, gw := range gws {
+		routes = append(routes, k8sv1alpha2.Route{Dst: dst, GW: gw})
+	}
+	return routes
+}
+
+// Calcuate route to get to left and right edge networks and other networks (not adjacent) in the chain
+// Routes to a neighbour hop have one gateway per ready pod of the hop, the
+// agent installs them as a single multipath route.
+func calculateDeploymentRoutes(cs *chainState, pod *corev1.Pod, pos int, ln []k8sv1alpha2.RoutingNetwork, rn []k8sv1alpha2.RoutingNetwork, networkList, deploymentList []string) (r RoutingInfo, err error) {
+
+	var nextLeftIPs []string
+	var nextRightIPs []string
+	num := len(deploymentList)
+
+	r = newRoutingInfo(pod)
 	// Calcluate IP addresses for next neighbours on both sides
 	if pos == 0 {
-		nextLeftIP = ln[0].GatewayIP
+		nextLeftIPs = []string{ln[0].GatewayIP}
 	} else {
-		name := strings.Split(deploymentList[pos-1], "=")
-		nextLeftIP, err = ovn.GetIPAdressForPod(networkList[pos-1], name[1])
-		if err != nil {
-			return RoutingInfo{}, err
+		nextLeftIPs = cs.gateways(networkList[pos-1], pos-1)
+		if len(nextLeftIPs) == 0 {
+			return RoutingInfo{}, fmt.Errorf("No ready pod for previous hop %s", deploymentList[pos-1])
 		}
 	}
 	if pos == num-1 {
-		nextRightIP = rn[0].GatewayIP
+		nextRightIPs = []string{rn[0].GatewayIP}
 	} else {
-		name := strings.Split(deploymentList[pos+1], "=")
-		nextRightIP, err = ovn.GetIPAdressForPod(networkList[pos], name[1])
-		if err != nil {
-			return RoutingInfo{}, err
+		nextRightIPs = cs.gateways(networkList[pos], pos+1)
+		if len(nextRightIPs) == 0 {
+			return RoutingInfo{}, fmt.Errorf("No ready pod for next hop %s", deploymentList[pos+1])
+		}
+	}
+	// Calcuate left right Routes to be inserted in Pod, the edge hops
+	// reach each edge network through its own gateway
+	for _, n := range ln {
+		if pos == 0 {
+			r.LeftNetworkRoutes = append(r.LeftNetworkRoutes, k8sv1alpha2.Route{Dst: n.Subnet, GW: n.GatewayIP})
+		} else {
+			r.LeftNetworkRoutes = append(r.LeftNetworkRoutes, multipathRoutes(n.Subnet, nextLeftIPs)...)
+		}
+	}
+	for _, n := range rn {
+		if pos == num-1 {
+			r.RightNetworkRoutes = append(r.RightNetworkRoutes, k8sv1alpha2.Route{Dst: n.Subnet, GW: n.GatewayIP})
+		} else {
+			r.RightNetworkRoutes = append(r.RightNetworkRoutes, multipathRoutes(n.Subnet, nextRightIPs)...)
 		}
 	}
-	// Calcuate left right Route to be inserted in Pod
-	r.LeftNetworkRoute.Dst = ln[0].Subnet
-	r.LeftNetworkRoute.GW = nextLeftIP
-	r.RightNetworkRoute.Dst = rn[0].Subnet
-	r.RightNetworkRoute.GW = nextRightIP
 	// For each network that is not adjacent add route
 	for i := 0; i < len(networkList); i++ {
 		if i == pos || i == pos-1 {
 			continue
+		}
+		dst, err := cs.subnet(networkList[i])
+		if err != nil {
+			return RoutingInfo{}, err
+		}
+		if i > pos {
+			r.DynamicNetworkRoutes = append(r.DynamicNetworkRoutes, multipathRoutes(dst, nextRightIPs)...)
 		} else {
-			var rt k8sv1alpha1.Route
-			rt.Dst, err = ovn.GetNetworkSubnet(networkList[i])
-			if err != nil {
-				return RoutingInfo{}, err
-			}
-			if i > pos {
-				rt.GW = nextRightIP
-			} else {
-				rt.GW = nextLeftIP
-			}
-			r.DynamicNetworkRoutes = append(r.DynamicNetworkRoutes, rt)
+			r.DynamicNetworkRoutes = append(r.DynamicNetworkRoutes, multipathRoutes(dst, nextLeftIPs)...)
 		}
 	}
 
-	//Add Default Route based on Right Network
-	rt := k8sv1alpha1.Route{
-		GW:  nextRightIP,
-		Dst: "0.0.0.0",
-	}
-	r.DynamicNetworkRoutes = append(r.DynamicNetworkRoutes, rt)
+	//Add Default Route based on the first Right Network
+	r.DynamicNetworkRoutes = append(r.DynamicNetworkRoutes, multipathRoutes("0.0.0.0", nextRightIPs)...)
 	return
 }
 
-func CalculateRoutes(cr *k8sv1alpha1.NetworkChaining) ([]RoutingInfo, error) {
-	//
-	var deploymentList []string
-	var networkList []string
+// newRoutingInfo returns the routing info of the pod without routes
+func newRoutingInfo(pod *corev1.Pod) RoutingInfo {
+	r := RoutingInfo{
+		Name:      pod.GetName(),
+		Namespace: pod.Namespace,
+		Node:      pod.Spec.NodeName,
+	}
+	// Get the containerID of the first container, the agent finds the pod
+	// by name so any runtime is fine
+	if len(pod.Status.ContainerStatuses) > 0 {
+		id := pod.Status.ContainerStatuses[0].ContainerID
+		if i := strings.Index(id, "://"); i >= 0 {
+			id = id[i+3:]
+		}
+		r.Id = id
+	}
+	return r
+}
+
+// CalculateRoutes returns the routes of the pods in the chain and the health
+// of each hop. Routes are returned only for the healthy hops.
+func CalculateRoutes(c client.Client, cr *k8sv1alpha2.NetworkChaining) ([]RoutingInfo, []k8sv1alpha2.HopStatus, error) {
+	var chainRoutingInfo []RoutingInfo
 
-	// TODO: Add Validation of Input to this function
 	ln := cr.Spec.RoutingSpec.LeftNetwork
 	rn := cr.Spec.RoutingSpec.RightNetwork
-	chains := strings.Split(cr.Spec.RoutingSpec.NetworkChain, ",")
-	i := 0
-	for _, chain := range chains {
-		if i%2 == 0 {
-			deploymentList = append(deploymentList, chain)
-		} else {
-			networkList = append(networkList, chain)
+	cs, networkList, deploymentList, hops, err := newChainState(c, cr)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.V(1).Info("Calculate routes", "networkList", networkList, "deploymentList", deploymentList)
+
+	for i := range deploymentList {
+		for _, pod := range cs.hops[i] {
+			r, err := calculateDeploymentRoutes(cs, pod, i, ln, rn, networkList, deploymentList)
+			if err != nil {
+				hops[i].Message = err.Error()
+				continue
+			}
+			hops[i].Pods = append(hops[i].Pods, pod.Name)
+			chainRoutingInfo = append(chainRoutingInfo, r)
 		}
-		i++
+		hops[i].Healthy = len(hops[i].Pods) > 0
 	}
-	num := len(deploymentList)
-	log.Info("Display the num", "num", num)
-	log.Info("Display the ln", "ln", ln)
-	log.Info("Display the rn", "rn", rn)
-	log.Info("Display the networklist", "networkList", networkList)
-	log.Info("Display the deploymentlist", "deploymentList", deploymentList)
+	return chainRoutingInfo, hops, nil
+}
+
+// newChainState finds the ready pods of each hop of the chain
+func newChainState(c client.Client, cr *k8sv1alpha2.NetworkChaining) (cs *chainState, networkList, deploymentList []string, hops []k8sv1alpha2.HopStatus, err error) {
+	if len(cr.Spec.RoutingSpec.LeftNetwork) == 0 || len(cr.Spec.RoutingSpec.RightNetwork) == 0 {
+		return nil, nil, nil, nil, fmt.Errorf("Left and right networks are required")
+	}
+	selectors, networkList, err := ParseNetworkChain(cr.Spec.RoutingSpec)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	deploymentList = make([]string, len(selectors))
+	for i, selector := range selectors {
+		deploymentList[i] = selector.String()
+	}
+
+	cs = &chainState{
+		hops:      make([][]*corev1.Pod, len(deploymentList)),
+		ips:       make(map[string]string),
+		subnets:   make(map[string]string),
+		routerIPs: make(map[string]string),
+	}
+	hops = make([]k8sv1alpha2.HopStatus, len(deploymentList))
 	for i, deployment := range deploymentList {
-		r, err := calculateDeploymentRoutes(cr.Namespace, deployment, i, num, ln, rn, networkList, deploymentList)
+		hops[i].Deployment = deployment
+		pods, err := hopPods(c, cr.Namespace, selectors[i])
 		if err != nil {
-			return nil, err
+			hops[i].Message = err.Error()
+			continue
+		}
+		cs.hops[i] = pods
+	}
+	return cs, networkList, deploymentList, hops, nil
+}
+
+// CalculatePolicies returns the policies of the cluster router steering the
+// traffic between the left and right networks through the hops of the chain,
+// the routes of the pods of the hops and the health of each hop. Each hop
+// sends the traffic back to the router through the network towards its
+// destination, the router reroutes it to the next hop. The edge networks
+// must be connected to the cluster router.
+func CalculatePolicies(c client.Client, cr *k8sv1alpha2.NetworkChaining) ([]ovn.RouterPolicy, []RoutingInfo, []k8sv1alpha2.HopStatus, error) {
+	ln := cr.Spec.RoutingSpec.LeftNetwork
+	rn := cr.Spec.RoutingSpec.RightNetwork
+	cs, networkList, deploymentList, hops, err := newChainState(c, cr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	log.V(1).Info("Calculate policies", "networkList", networkList, "deploymentList", deploymentList)
+
+	var leftPorts, rightPorts, leftSubnets, rightSubnets []string
+	for _, n := range ln {
+		leftPorts = append(leftPorts, fmt.Sprintf("%q", ovn.RouterPortName(n.NetworkName)))
+		leftSubnets = append(leftSubnets, n.Subnet)
+	}
+	for _, n := range rn {
+		rightPorts = append(rightPorts, fmt.Sprintf("%q", ovn.RouterPortName(n.NetworkName)))
+		rightSubnets = append(rightSubnets, n.Subnet)
+	}
+	match := func(ports, src, dst []string) string {
+		return fmt.Sprintf("inport == {%s} && ip4.src == {%s} && ip4.dst == {%s}",
+			strings.Join(ports, ", "), strings.Join(src, ", "), strings.Join(dst, ", "))
+	}
+
+	num := len(deploymentList)
+	// Nexthops of the hops on the network towards the previous and the
+	// next hop
+	ingress := make([][]string, num)
+	egress := make([][]string, num)
+	var chainRoutingInfo []RoutingInfo
+	for i := range deploymentList {
+		for _, pod := range cs.hops[i] {
+			var in, out string
+			if i == 0 {
+				in = cs.edgeNetwork(ln, pod)
+			} else {
+				in = networkList[i-1]
+			}
+			if i == num-1 {
+				out = cs.edgeNetwork(rn, pod)
+			} else {
+				out = networkList[i]
+			}
+			// Only the pods attached to both networks of the hop
+			// forward its traffic
+			if in == "" || out == "" || cs.ip(in, pod) == "" || cs.ip(out, pod) == "" {
+				continue
+			}
+			r := newRoutingInfo(pod)
+			if r.LeftNetworkRoutes, err = cs.routerRoutes(in, ln); err != nil {
+				return nil, nil, nil, err
+			}
+			if r.RightNetworkRoutes, err = cs.routerRoutes(out, rn); err != nil {
+				return nil, nil, nil, err
+			}
+			ingress[i] = append(ingress[i], cs.ip(in, pod))
+			egress[i] = append(egress[i], cs.ip(out, pod))
+			hops[i].Pods = append(hops[i].Pods, pod.Name)
+			chainRoutingInfo = append(chainRoutingInfo, r)
+		}
+		hops[i].Healthy = len(hops[i].Pods) > 0
+		if !hops[i].Healthy && hops[i].Message == "" {
+			hops[i].Message = "No ready pod attached to both networks of the hop"
+		}
+	}
+
+	// Policies are only returned when all the hops are healthy, the
+	// traffic of a chain missing a hop is no longer steered
+	for i := range hops {
+		if !hops[i].Healthy {
+			return nil, chainRoutingInfo, hops, nil
+		}
+	}
+	var policies []ovn.RouterPolicy
+	// Left to right, from the left networks and from each hop to the next
+	policies = append(policies, ovn.RouterPolicy{
+		Match:    match(leftPorts, leftSubnets, rightSubnets),
+		Nexthops: ingress[0],
+	})
+	for i := 0; i < num-1; i++ {
+		policies = append(policies, ovn.RouterPolicy{
+			Match:    match([]string{fmt.Sprintf("%q", ovn.RouterPortName(networkList[i]))}, leftSubnets, rightSubnets),
+			Nexthops: ingress[i+1],
+		})
+	}
+	// Right to left, from the right networks and from each hop to the
+	// previous one
+	policies = append(policies, ovn.RouterPolicy{
+		Match:    match(rightPorts, rightSubnets, leftSubnets),
+		Nexthops: egress[num-1],
+	})
+	for i := num - 1; i > 0; i-- {
+		policies = append(policies, ovn.RouterPolicy{
+			Match:    match([]string{fmt.Sprintf("%q", ovn.RouterPortName(networkList[i-1]))}, rightSubnets, leftSubnets),
+			Nexthops: egress[i-1],
+		})
+	}
+	return policies, chainRoutingInfo, hops, nil
+}
+
+// PodRoutes returns the routes installed in the pods
+func PodRoutes(chainRoutingInfo []RoutingInfo) []k8sv1alpha2.PodRoutes {
+	var installed []k8sv1alpha2.PodRoutes
+	for _, r := range chainRoutingInfo {
+		installed = append(installed, k8sv1alpha2.PodRoutes{
+			Namespace:   r.Namespace,
+			Name:        r.Name,
+			Node:        r.Node,
+			ContainerID: r.Id,
+			Routes:      r.Routes(),
+		})
+	}
+	return installed
+}
+
+// RouteDelta returns the routes to be removed from and inserted in the pods
+// to go from the installed routes to the new routes. Routes to the same
+// destination form a multipath route, so when any of its gateways changes
+// all the routes to the destination are inserted again.
+func RouteDelta(installed []k8sv1alpha2.PodRoutes, chainRoutingInfo []RoutingInfo) (remove, insert []RoutingInfo) {
+	oldRoutes := make(map[string]k8sv1alpha2.PodRoutes)
+	for _, pr := range installed {
+		oldRoutes[pr.Namespace+"/"+pr.Name] = pr
+	}
+	newPods := make(map[string]bool)
+	for _, r := range chainRoutingInfo {
+		key := r.Namespace + "/" + r.Name
+		newPods[key] = true
+		pr, ok := oldRoutes[key]
+		if !ok || pr.ContainerID != r.Id || pr.Node != r.Node {
+			// New pod or new container, install all routes
+			insert = append(insert, r)
+			continue
+		}
+		routes := r.Routes()
+		oldDst := routesByDst(pr.Routes)
+		newDst := routesByDst(routes)
+		var add, rm []k8sv1alpha2.Route
+		for _, rt := range routes {
+			if !sameRoutes(newDst[rt.Dst], oldDst[rt.Dst]) {
+				add = append(add, rt)
+			}
+		}
+		for _, rt := range pr.Routes {
+			// Changed destinations are replaced by the new routes
+			if _, ok := newDst[rt.Dst]; !ok {
+				rm = append(rm, rt)
+			}
+		}
+		if len(rm) > 0 {
+			remove = append(remove, podRoutingInfo(pr, rm))
+		}
+		if len(add) > 0 {
+			insert = append(insert, podRoutingInfo(pr, add))
+		}
+	}
+	for _, pr := range installed {
+		if !newPods[pr.Namespace+"/"+pr.Name] {
+			// Pod left the chain
+			r := podRoutingInfo(pr, pr.Routes)
+			r.LeaveChain = true
+			remove = append(remove, r)
+		}
+	}
+	return remove, insert
+}
+
+func podRoutingInfo(pr k8sv1alpha2.PodRoutes, routes []k8sv1alpha2.Route) RoutingInfo {
+	return RoutingInfo{
+		Name:                 pr.Name,
+		Namespace:            pr.Namespace,
+		Id:                   pr.ContainerID,
+		Node:                 pr.Node,
+		DynamicNetworkRoutes: routes,
+	}
+}
+
+// routesByDst groups the routes by destination
+func routesByDst(routes []k8sv1alpha2.Route) map[string][]k8sv1alpha2.Route {
+	m := make(map[string][]k8sv1alpha2.Route)
+	for _, rt := range routes {
+		m[rt.Dst] = append(m[rt.Dst], rt)
+	}
+	return m
+}
+
+func sameRoutes(a, b []k8sv1alpha2.Route) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// nexthops groups the gateways of the routes by destination, keeping the
+// order of the destinations
+func nexthops(route []*pb.RouteData) (dsts []string, gws map[string][]string) {
+	gws = make(map[string][]string)
+	for _, r := range route {
+		dst := r.GetDst()
+		if _, ok := gws[dst]; !ok {
+			dsts = append(dsts, dst)
 		}
-		chainRoutingInfo = append(chainRoutingInfo, r)
+		gws[dst] = append(gws[dst], r.GetGw())
 	}
-	return chainRoutingInfo, nil
+	return dsts, gws
 }
 
-func ContainerAddRoute(containerPid int, route []*pb.RouteData) error {
-	str := fmt.Sprintf("/host/proc/%d/ns/net", containerPid)
+// viaArgs returns the ip route arguments for a single or multipath route
+func viaArgs(gws []string) []string {
+	if len(gws) == 1 {
+		return []string{"via", gws[0]}
+	}
+	var args []string
+	for _, gw := range gws {
+		args = append(args, "nexthop", "via", gw)
+	}
+	return args
+}
 
+// ContainerAddRoute adds routes in the pod network namespace. defaultGW is
+// the pod default gateway before any chaining route was added.
+func ContainerAddRoute(netnsPath, defaultGW string, route []*pb.RouteData) error {
 	hostNet, err := network.GetHostNetwork()
 	if err != nil {
 		log.Error(err, "Failed to get host network")
 		return err
 	}
 
-	nms, err := ns.GetNS(str)
+	nms, err := ns.GetNS(netnsPath)
 	if err != nil {
-		log.Error(err, "Failed namesapce", "containerID", containerPid)
+		log.Error(err, "Failed namesapce", "netns", netnsPath)
 		return err
 	}
 	defer nms.Close()
 	err = nms.Do(func(_ ns.NetNS) error {
-		podGW, err := network.GetDefaultGateway()
-		if err != nil {
-			log.Error(err, "Failed to get pod default gateway")
-			return err
+		podGW := defaultGW
+		if podGW == "" {
+			podGW, err = network.GetDefaultGateway()
+			if err != nil {
+				log.Error(err, "Failed to get pod default gateway")
+				return err
+			}
 		}
+		return addRoutes(hostNet, podGW, route)
+	})
+	if err != nil {
+		log.Error(err, "Failed Netns Do", "netns", netnsPath)
+		return err
+	}
+	return nil
+}
 
-		stdout, stderr, err := ovn.RunIP("route", "add", hostNet, "via", podGW)
-		if err != nil && !strings.Contains(stderr, "RTNETLINK answers: File exists") {
-			log.Error(err, "Failed to ip route add", "stdout", stdout, "stderr", stderr)
+// addRoutes adds the routes in the current network namespace. The host
+// network stays reachable through the pod gateway podGW.
+func addRoutes(hostNet, podGW string, route []*pb.RouteData) error {
+	stdout, stderr, err := ovn.RunIP("route", "add", hostNet, "via", podGW)
+	if err != nil && !strings.Contains(stderr, "RTNETLINK answers: File exists") {
+		log.Error(err, "Failed to ip route add", "stdout", stdout, "stderr", stderr)
+		return err
+	}
+
+	dsts, gws := nexthops(route)
+	for _, dst := range dsts {
+		// Replace default route
+		prefix := dst
+		if dst == "0.0.0.0" {
+			prefix = "default"
+		}
+		// Routes to a destination are always sent with all their
+		// gateways, replace updates the nexthops of existing routes
+		args := append([]string{"route", "replace", prefix}, viaArgs(gws[dst])...)
+		stdout, stderr, err := ovn.RunIP(args...)
+		if err != nil {
+			log.Error(err, "Failed to ip route replace", "stdout", stdout, "stderr", stderr)
 			return err
 		}
+	}
+	return nil
+}
+
+// ContainerDeleteRoute removes routes added by ContainerAddRoute from the
+// pod network namespace. When the pod leaves the chain the default route is
+// restored to defaultGW.
+func ContainerDeleteRoute(netnsPath, defaultGW string, route []*pb.RouteData, leaveChain bool) error {
+	hostNet, err := network.GetHostNetwork()
+	if err != nil {
+		log.Error(err, "Failed to get host network")
+		return err
+	}
 
-		for _, r := range route {
-			dst := r.GetDst()
-			gw := r.GetGw()
-			// Replace default route
-			if dst == "0.0.0.0" {
-				stdout, stderr, err := ovn.RunIP("route", "replace", "default", "via", gw)
+	nms, err := ns.GetNS(netnsPath)
+	if err != nil {
+		log.Error(err, "Failed namesapce", "netns", netnsPath)
+		return err
+	}
+	defer nms.Close()
+	err = nms.Do(func(_ ns.NetNS) error {
+		return deleteRoutes(hostNet, defaultGW, route, leaveChain)
+	})
+	if err != nil {
+		log.Error(err, "Failed Netns Do", "netns", netnsPath)
+		return err
+	}
+	return nil
+}
+
+// deleteRoutes removes the routes added by addRoutes from the current network
+// namespace. The other gateways of a multipath route stay while the pod is in
+// the chain. When it leaves the chain the route to the host network is removed
+// too and the default route restored to defaultGW.
+func deleteRoutes(hostNet, defaultGW string, route []*pb.RouteData, leaveChain bool) error {
+	dsts, gws := nexthops(route)
+	for _, dst := range dsts {
+		prefix := dst
+		if dst == "0.0.0.0" {
+			prefix = "default"
+		}
+		if !leaveChain {
+			remaining, err := remainingGateways(prefix, gws[dst])
+			if err != nil {
+				return err
+			}
+			if len(remaining) > 0 {
+				args := append([]string{"route", "replace", prefix}, viaArgs(remaining)...)
+				stdout, stderr, err := ovn.RunIP(args...)
 				if err != nil {
 					log.Error(err, "Failed to ip route replace", "stdout", stdout, "stderr", stderr)
 					return err
 				}
-			} else {
-				stdout, stderr, err := ovn.RunIP("route", "add", dst, "via", gw)
-				if err != nil && !strings.Contains(stderr, "RTNETLINK answers: File exists") {
-					log.Error(err, "Failed to ip route add", "stdout", stdout, "stderr", stderr)
-					return err
-				}
+				continue
 			}
 		}
+		if dst == "0.0.0.0" {
+			if defaultGW == "" {
+				log.Info("Original default gateway unknown, default route not restored")
+				continue
+			}
+			stdout, stderr, err := ovn.RunIP("route", "replace", "default", "via", defaultGW)
+			if err != nil {
+				log.Error(err, "Failed to ip route replace", "stdout", stdout, "stderr", stderr)
+				return err
+			}
+		} else {
+			stdout, stderr, err := ovn.RunIP("route", "del", dst)
+			if err != nil && !strings.Contains(stderr, "No such process") {
+				log.Error(err, "Failed to ip route del", "stdout", stdout, "stderr", stderr)
+				return err
+			}
+		}
+	}
+	if !leaveChain {
 		return nil
-	})
-	if err != nil {
-		log.Error(err, "Failed Netns Do", "containerID", containerPid)
+	}
+	// The route was added through the original default gateway, whatever
+	// its gateway the route is deleted when that one is unknown
+	args := []string{"route", "del", hostNet}
+	if defaultGW != "" {
+		args = append(args, "via", defaultGW)
+	}
+	stdout, stderr, err := ovn.RunIP(args...)
+	if err != nil && !strings.Contains(stderr, "No such process") {
+		log.Error(err, "Failed to ip route del", "stdout", stdout, "stderr", stderr)
 		return err
 	}
 	return nil
 }
 
-func GetPidForContainer(id string) (int, error) {
-	cli, err := client.NewEnvClient()
+// remainingGateways returns the gateways of the route to prefix in the current
+// network namespace other than gws
+func remainingGateways(prefix string, gws []string) ([]string, error) {
+	stdout, stderr, err := ovn.RunIP("route", "show", prefix)
 	if err != nil {
-		fmt.Println("Unable to create docker client")
-		return 0, err
+		log.Error(err, "Failed to ip route show", "stdout", stdout, "stderr", stderr)
+		return nil, err
 	}
-	cli.NegotiateAPIVersion(context.Background())
-	cj, err := cli.ContainerInspect(context.Background(), id)
-	if err != nil {
-		fmt.Println("Unable to Inspect docker container")
-		return 0, err
+	removed := make(map[string]bool)
+	for _, gw := range gws {
+		removed[gw] = true
 	}
-	if cj.State.Pid == 0 {
-		return 0, fmt.Errorf("Container not found %s", id)
+	var remaining []string
+	fields := strings.Fields(stdout)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "via" && !removed[fields[i+1]] {
+			remaining = append(remaining, fields[i+1])
+		}
 	}
-	return cj.State.Pid, nil
-
+	return remaining, nil
 }
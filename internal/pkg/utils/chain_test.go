@@ -0,0 +1,333 @@
+package nfn
+
+import (
+	"fmt"
+	"testing"
+
+	pb "ovn4nfv-k8s-plugin/internal/pkg/nfnNotify/proto"
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	ovntest "ovn4nfv-k8s-plugin/internal/pkg/testing"
+	k8sv1alpha2 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	fakeexec "k8s.io/utils/exec/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestChain(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Chain Test Suite")
+}
+
+var _ = Describe("Test route delta", func() {
+	installed := []k8sv1alpha2.PodRoutes{
+		{
+			Namespace:   "default",
+			Name:        "ngfw",
+			Node:        "node1",
+			ContainerID: "c1",
+			Routes: []k8sv1alpha2.Route{
+				{Dst: "172.30.10.0/24", GW: "172.30.11.2"},
+				{Dst: "0.0.0.0", GW: "172.30.12.2"},
+			},
+		},
+		{
+			Namespace:   "default",
+			Name:        "sdwan",
+			Node:        "node2",
+			ContainerID: "c2",
+			Routes: []k8sv1alpha2.Route{
+				{Dst: "172.30.10.0/24", GW: "172.30.12.3"},
+			},
+		},
+	}
+
+	It("sends nothing when routes are unchanged", func() {
+		remove, insert := RouteDelta(installed, []RoutingInfo{
+			podRoutingInfo(installed[0], installed[0].Routes),
+			podRoutingInfo(installed[1], installed[1].Routes),
+		})
+		Expect(remove).To(BeEmpty())
+		Expect(insert).To(BeEmpty())
+	})
+
+	It("sends only changed routes and keeps the new default route", func() {
+		routes := []k8sv1alpha2.Route{
+			{Dst: "172.30.10.0/24", GW: "172.30.11.2"},
+			{Dst: "0.0.0.0", GW: "172.30.12.9"},
+		}
+		remove, insert := RouteDelta(installed, []RoutingInfo{
+			podRoutingInfo(installed[0], routes),
+			podRoutingInfo(installed[1], installed[1].Routes),
+		})
+		Expect(remove).To(BeEmpty())
+		Expect(insert).To(HaveLen(1))
+		Expect(insert[0].Routes()).To(Equal([]k8sv1alpha2.Route{{Dst: "0.0.0.0", GW: "172.30.12.9"}}))
+	})
+
+	It("inserts all gateways of a multipath route when a replica joins", func() {
+		routes := []k8sv1alpha2.Route{
+			{Dst: "172.30.10.0/24", GW: "172.30.11.2"},
+			{Dst: "0.0.0.0", GW: "172.30.12.2"},
+			{Dst: "0.0.0.0", GW: "172.30.12.4"},
+		}
+		remove, insert := RouteDelta(installed, []RoutingInfo{
+			podRoutingInfo(installed[0], routes),
+			podRoutingInfo(installed[1], installed[1].Routes),
+		})
+		Expect(remove).To(BeEmpty())
+		Expect(insert).To(HaveLen(1))
+		Expect(insert[0].Routes()).To(Equal(routes[1:]))
+	})
+
+	It("removes destinations no longer routed", func() {
+		remove, insert := RouteDelta(installed, []RoutingInfo{
+			podRoutingInfo(installed[0], installed[0].Routes[1:]),
+			podRoutingInfo(installed[1], installed[1].Routes),
+		})
+		Expect(insert).To(BeEmpty())
+		Expect(remove).To(HaveLen(1))
+		Expect(remove[0].Routes()).To(Equal(installed[0].Routes[:1]))
+	})
+
+	It("installs all routes on a new container and removes routes of pods that left", func() {
+		ngfw := podRoutingInfo(installed[0], installed[0].Routes)
+		ngfw.Id = "c3"
+		remove, insert := RouteDelta(installed, []RoutingInfo{ngfw})
+		Expect(insert).To(HaveLen(1))
+		Expect(insert[0].Routes()).To(Equal(installed[0].Routes))
+		Expect(remove).To(HaveLen(1))
+		Expect(remove[0].Name).To(Equal("sdwan"))
+		Expect(remove[0].Routes()).To(Equal(installed[1].Routes))
+		Expect(remove[0].LeaveChain).To(BeTrue())
+	})
+})
+
+var _ = Describe("Test pod routes", func() {
+	routes := []*pb.RouteData{
+		{Dst: "172.30.10.0/24", Gw: "172.30.11.2"},
+		{Dst: "0.0.0.0", Gw: "172.30.12.2"},
+		{Dst: "0.0.0.0", Gw: "172.30.12.4"},
+	}
+
+	runIP := func(cmds []string, run func()) {
+		fexec := &fakeexec.FakeExec{
+			LookPathFunc: func(file string) (string, error) {
+				return "/fake-bin/" + file, nil
+			},
+		}
+		fexec.CommandScript = ovntest.AddFakeCmdsNoOutputNoError(fexec.CommandScript, cmds)
+		Expect(ovn.SetExec(fexec)).To(Succeed())
+		run()
+		Expect(fexec.CommandCalls).To(Equal(len(cmds)))
+	}
+
+	It("removes the routes it added along with the host network route", func() {
+		runIP([]string{
+			"ip route add 10.10.0.0/16 via 10.233.64.1",
+			"ip route replace 172.30.10.0/24 via 172.30.11.2",
+			"ip route replace default nexthop via 172.30.12.2 nexthop via 172.30.12.4",
+		}, func() {
+			Expect(addRoutes("10.10.0.0/16", "10.233.64.1", routes)).To(Succeed())
+		})
+		runIP([]string{
+			"ip route del 172.30.10.0/24",
+			"ip route replace default via 10.233.64.1",
+			"ip route del 10.10.0.0/16 via 10.233.64.1",
+		}, func() {
+			Expect(deleteRoutes("10.10.0.0/16", "10.233.64.1", routes, true)).To(Succeed())
+		})
+	})
+
+	It("removes the host network route without the original default gateway", func() {
+		runIP([]string{
+			"ip route del 172.30.10.0/24",
+			"ip route del 10.10.0.0/16",
+		}, func() {
+			Expect(deleteRoutes("10.10.0.0/16", "", routes, true)).To(Succeed())
+		})
+	})
+
+	It("removes only the routes of the delta while the pod stays in the chain", func() {
+		installed := []k8sv1alpha2.PodRoutes{{
+			Namespace:   "default",
+			Name:        "ngfw",
+			Node:        "node1",
+			ContainerID: "c1",
+			Routes: []k8sv1alpha2.Route{
+				{Dst: "172.30.10.0/24", GW: "172.30.11.2"},
+				{Dst: "172.30.30.0/24", GW: "172.30.11.3"},
+				{Dst: "0.0.0.0", GW: "172.30.12.2"},
+				{Dst: "0.0.0.0", GW: "172.30.12.4"},
+			},
+		}}
+		// A destination is no longer routed and a replica left the
+		// multipath default route
+		remove, insert := RouteDelta(installed, []RoutingInfo{podRoutingInfo(installed[0], []k8sv1alpha2.Route{
+			{Dst: "172.30.10.0/24", GW: "172.30.11.2"},
+			{Dst: "0.0.0.0", GW: "172.30.12.4"},
+		})})
+		Expect(remove).To(HaveLen(1))
+		Expect(remove[0].LeaveChain).To(BeFalse())
+		Expect(insert).To(HaveLen(1))
+
+		fexec := &fakeexec.FakeExec{
+			LookPathFunc: func(file string) (string, error) {
+				return "/fake-bin/" + file, nil
+			},
+		}
+		for _, cmd := range []ovntest.ExpectedCmd{
+			{Cmd: "ip route show 172.30.30.0/24", Output: "172.30.30.0/24 via 172.30.11.3 dev net1"},
+			{Cmd: "ip route del 172.30.30.0/24"},
+			{Cmd: "ip route add 10.10.0.0/16 via 10.233.64.1", Stderr: "RTNETLINK answers: File exists", Err: fmt.Errorf("exit status 2")},
+			{Cmd: "ip route replace default via 172.30.12.4"},
+		} {
+			cmd := cmd
+			fexec.CommandScript = ovntest.AddFakeCmd(fexec.CommandScript, &cmd)
+		}
+		Expect(ovn.SetExec(fexec)).To(Succeed())
+		Expect(deleteRoutes("10.10.0.0/16", "10.233.64.1", remove[0].RouteData(), remove[0].LeaveChain)).To(Succeed())
+		Expect(addRoutes("10.10.0.0/16", "10.233.64.1", insert[0].RouteData())).To(Succeed())
+		Expect(fexec.CommandCalls).To(Equal(4))
+	})
+
+	It("rewrites a multipath route left by some of its gateways", func() {
+		fexec := &fakeexec.FakeExec{
+			LookPathFunc: func(file string) (string, error) {
+				return "/fake-bin/" + file, nil
+			},
+		}
+		for _, cmd := range []ovntest.ExpectedCmd{
+			{Cmd: "ip route show default", Output: "default proto static\n\tnexthop via 172.30.12.2 dev net2 weight 1\n\tnexthop via 172.30.12.4 dev net2 weight 1"},
+			{Cmd: "ip route replace default via 172.30.12.4"},
+		} {
+			cmd := cmd
+			fexec.CommandScript = ovntest.AddFakeCmd(fexec.CommandScript, &cmd)
+		}
+		Expect(ovn.SetExec(fexec)).To(Succeed())
+		Expect(deleteRoutes("10.10.0.0/16", "10.233.64.1", []*pb.RouteData{{Dst: "0.0.0.0", Gw: "172.30.12.2"}}, false)).To(Succeed())
+		Expect(fexec.CommandCalls).To(Equal(2))
+	})
+})
+
+var _ = Describe("Test chain policies", func() {
+	var ips map[string]string
+
+	gateways := map[string]string{
+		"left-net":  "172.30.10.1",
+		"dync-net1": "172.30.11.1",
+		"right-net": "172.30.20.1",
+	}
+	cr := &k8sv1alpha2.NetworkChaining{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "chain"},
+		Spec: k8sv1alpha2.NetworkChainingSpec{
+			ChainType: k8sv1alpha2.RouterPolicyChain,
+			RoutingSpec: k8sv1alpha2.RouteSpec{
+				LeftNetwork: []k8sv1alpha2.RoutingNetwork{
+					{NetworkName: "left-net", GatewayIP: "172.30.10.2", Subnet: "172.30.10.0/24"},
+					{NetworkName: "left-net2", GatewayIP: "172.30.30.2", Subnet: "172.30.30.0/24"},
+				},
+				RightNetwork: []k8sv1alpha2.RoutingNetwork{
+					{NetworkName: "right-net", GatewayIP: "172.30.20.2", Subnet: "172.30.20.0/24"},
+				},
+				Hops: []k8sv1alpha2.ChainHop{
+					{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "slb"}}, EgressNetwork: "dync-net1"},
+					{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "ngfw"}}, IngressNetwork: "dync-net1"},
+				},
+			},
+		},
+	}
+	newPod := func(name, app string) runtime.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{NodeName: "node1"},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				ContainerStatuses: []corev1.ContainerStatus{{ContainerID: "docker://" + name}},
+			},
+		}
+	}
+	routingInfo := func(name string, left, right []k8sv1alpha2.Route) RoutingInfo {
+		return RoutingInfo{Name: name, Namespace: "default", Id: name, Node: "node1",
+			LeftNetworkRoutes: left, RightNetworkRoutes: right}
+	}
+	slb := routingInfo("slb-0",
+		[]k8sv1alpha2.Route{{Dst: "172.30.30.0/24", GW: "172.30.10.1"}},
+		[]k8sv1alpha2.Route{{Dst: "172.30.20.0/24", GW: "172.30.11.1"}})
+	ngfw := func(name string) RoutingInfo {
+		return routingInfo(name, []k8sv1alpha2.Route{
+			{Dst: "172.30.10.0/24", GW: "172.30.11.1"},
+			{Dst: "172.30.30.0/24", GW: "172.30.11.1"},
+		}, nil)
+	}
+	leftToRight := `inport == {%s} && ip4.src == {172.30.10.0/24, 172.30.30.0/24} && ip4.dst == {172.30.20.0/24}`
+	rightToLeft := `inport == {%s} && ip4.src == {172.30.20.0/24} && ip4.dst == {172.30.10.0/24, 172.30.30.0/24}`
+	policies := func(ngfwIngress, ngfwEgress []string) []ovn.RouterPolicy {
+		return []ovn.RouterPolicy{
+			{Match: fmt.Sprintf(leftToRight, `"rtos-left-net", "rtos-left-net2"`), Nexthops: []string{"172.30.10.3"}},
+			{Match: fmt.Sprintf(leftToRight, `"rtos-dync-net1"`), Nexthops: ngfwIngress},
+			{Match: fmt.Sprintf(rightToLeft, `"rtos-right-net"`), Nexthops: ngfwEgress},
+			{Match: fmt.Sprintf(rightToLeft, `"rtos-dync-net1"`), Nexthops: []string{"172.30.11.3"}},
+		}
+	}
+
+	savedPodIP, savedNetworkGateway := podIP, networkGateway
+	AfterEach(func() {
+		podIP, networkGateway = savedPodIP, savedNetworkGateway
+	})
+
+	BeforeEach(func() {
+		ips = map[string]string{
+			"left-net/slb-0":   "172.30.10.3",
+			"dync-net1/slb-0":  "172.30.11.3",
+			"dync-net1/ngfw-0": "172.30.11.4",
+			"right-net/ngfw-0": "172.30.20.4",
+			"dync-net1/ngfw-1": "172.30.11.5",
+			"right-net/ngfw-1": "172.30.20.5",
+		}
+		podIP = func(network string, pod *corev1.Pod) (string, error) {
+			if ip, ok := ips[network+"/"+pod.Name]; ok {
+				return ip, nil
+			}
+			return "", fmt.Errorf("No port on %s", network)
+		}
+		networkGateway = func(network string) (string, error) {
+			return gateways[network], nil
+		}
+	})
+
+	table.DescribeTable("steers the traffic through the healthy hops",
+		func(missing []string, expectedPolicies []ovn.RouterPolicy, expectedRoutes []RoutingInfo, healthy []bool) {
+			for _, key := range missing {
+				delete(ips, key)
+			}
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			c := fake.NewFakeClientWithScheme(scheme, newPod("slb-0", "slb"), newPod("ngfw-0", "ngfw"), newPod("ngfw-1", "ngfw"))
+
+			p, routes, hops, err := CalculatePolicies(c, cr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p).To(Equal(expectedPolicies))
+			Expect(routes).To(Equal(expectedRoutes))
+			Expect(hops).To(HaveLen(len(healthy)))
+			for i := range hops {
+				Expect(hops[i].Healthy).To(Equal(healthy[i]))
+			}
+		},
+		table.Entry("all the pods attached", nil,
+			policies([]string{"172.30.11.4", "172.30.11.5"}, []string{"172.30.20.4", "172.30.20.5"}),
+			[]RoutingInfo{slb, ngfw("ngfw-0"), ngfw("ngfw-1")}, []bool{true, true}),
+		table.Entry("a pod not attached to the egress network yet", []string{"right-net/ngfw-1"},
+			policies([]string{"172.30.11.4"}, []string{"172.30.20.4"}),
+			[]RoutingInfo{slb, ngfw("ngfw-0")}, []bool{true, true}),
+		table.Entry("a hop without attached pod", []string{"dync-net1/ngfw-0", "dync-net1/ngfw-1"},
+			nil, []RoutingInfo{slb}, []bool{true, false}),
+	)
+})
@@ -0,0 +1,68 @@
+package condition
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionStatus is the status of a condition, one of True, False or Unknown
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+const (
+	// ConditionReady is True when the object is applied
+	ConditionReady = "Ready"
+	// ConditionDegraded is True when only part of the object is applied
+	ConditionDegraded = "Degraded"
+	// ConditionProgressing is True while the object is being applied
+	ConditionProgressing = "Progressing"
+)
+
+// Condition is an observation of the state of an object. It follows
+// metav1.Condition of newer Kubernetes releases.
+// +k8s:openapi-gen=true
+type Condition struct {
+	Type               string          `json:"type"`                         // Ready, Degraded or Progressing
+	Status             ConditionStatus `json:"status"`                       // True, False or Unknown
+	ObservedGeneration int64           `json:"observedGeneration,omitempty"` // Generation of the object the condition was set for
+	LastTransitionTime metav1.Time     `json:"lastTransitionTime"`           // Last time the status changed
+	Reason             string          `json:"reason"`                       // CamelCase reason of the last transition
+	Message            string          `json:"message,omitempty"`            // Human readable details
+}
+
+// SetCondition adds the condition or updates the condition of the same type.
+// The transition time is kept unless the status changes.
+func SetCondition(conditions *[]Condition, condition Condition) {
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	existing := FindCondition(*conditions, condition.Type)
+	if existing == nil {
+		*conditions = append(*conditions, condition)
+		return
+	}
+	if existing.Status == condition.Status {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	}
+	*existing = condition
+}
+
+// FindCondition returns the condition of the type or nil
+func FindCondition(conditions []Condition, conditionType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true if the condition of the type is True
+func IsConditionTrue(conditions []Condition, conditionType string) bool {
+	c := FindCondition(conditions, conditionType)
+	return c != nil && c.Status == ConditionTrue
+}
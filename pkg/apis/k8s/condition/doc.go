@@ -0,0 +1,4 @@
+// Package condition contains the status conditions shared by the versions of
+// the k8s API group
+// +k8s:deepcopy-gen=package
+package condition
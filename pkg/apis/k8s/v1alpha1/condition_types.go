@@ -0,0 +1,44 @@
+package v1alpha1
+
+import (
+	"ovn4nfv-k8s-plugin/pkg/apis/k8s/condition"
+)
+
+// The conditions are shared by the versions of the API group
+
+// ConditionStatus is the status of a condition, one of True, False or Unknown
+type ConditionStatus = condition.ConditionStatus
+
+const (
+	ConditionTrue    = condition.ConditionTrue
+	ConditionFalse   = condition.ConditionFalse
+	ConditionUnknown = condition.ConditionUnknown
+)
+
+const (
+	// ConditionReady is True when the object is applied
+	ConditionReady = condition.ConditionReady
+	// ConditionDegraded is True when only part of the object is applied
+	ConditionDegraded = condition.ConditionDegraded
+	// ConditionProgressing is True while the object is being applied
+	ConditionProgressing = condition.ConditionProgressing
+)
+
+// Condition is an observation of the state of an object
+type Condition = condition.Condition
+
+// SetCondition adds the condition or updates the condition of the same type.
+// The transition time is kept unless the status changes.
+func SetCondition(conditions *[]Condition, c Condition) {
+	condition.SetCondition(conditions, c)
+}
+
+// FindCondition returns the condition of the type or nil
+func FindCondition(conditions []Condition, conditionType string) *Condition {
+	return condition.FindCondition(conditions, conditionType)
+}
+
+// IsConditionTrue returns true if the condition of the type is True
+func IsConditionTrue(conditions []Condition, conditionType string) bool {
+	return condition.IsConditionTrue(conditions, conditionType)
+}
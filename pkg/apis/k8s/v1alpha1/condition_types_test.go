@@ -0,0 +1,32 @@
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Test conditions", func() {
+	It("adds and updates conditions by type", func() {
+		var conditions []Condition
+		SetCondition(&conditions, Condition{Type: ConditionReady, Status: ConditionFalse, Reason: "Applying"})
+		SetCondition(&conditions, Condition{Type: ConditionDegraded, Status: ConditionFalse, Reason: "Applying"})
+		SetCondition(&conditions, Condition{Type: ConditionReady, Status: ConditionTrue, Reason: "Applied"})
+		Expect(conditions).To(HaveLen(2))
+		Expect(IsConditionTrue(conditions, ConditionReady)).To(BeTrue())
+		Expect(IsConditionTrue(conditions, ConditionDegraded)).To(BeFalse())
+		Expect(FindCondition(conditions, ConditionProgressing)).To(BeNil())
+	})
+
+	It("keeps the transition time unless the status changes", func() {
+		then := metav1.NewTime(time.Now().Add(-time.Hour))
+		conditions := []Condition{{Type: ConditionReady, Status: ConditionFalse, Reason: "Applying", LastTransitionTime: then}}
+		SetCondition(&conditions, Condition{Type: ConditionReady, Status: ConditionFalse, Reason: "Applying", Message: "Applied on 1/2 nodes"})
+		Expect(conditions[0].LastTransitionTime).To(Equal(then))
+		Expect(conditions[0].Message).To(Equal("Applied on 1/2 nodes"))
+		SetCondition(&conditions, Condition{Type: ConditionReady, Status: ConditionTrue, Reason: "Applied"})
+		Expect(conditions[0].LastTransitionTime.After(then.Time)).To(BeTrue())
+	})
+})
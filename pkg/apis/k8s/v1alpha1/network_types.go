@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	"ovn4nfv-k8s-plugin/pkg/apis/k8s/condition"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -46,6 +48,8 @@ const (
 	CreateInternalError = "CreateInternalError"
 	//DeleteInternalError indicates delete internal irrecoverable Error
 	DeleteInternalError = "DeleteInternalError"
+	//Degraded indicates that only part of the object could be applied
+	Degraded = "Degraded"
 )
 
 // NetworkStatus defines the observed state of Network
@@ -54,7 +58,9 @@ type NetworkStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
-	State string `json:"state"` // Indicates if Network is in "created" state
+	State              string                `json:"state"`                        // Indicates if Network is in "created" state
+	ObservedGeneration int64                 `json:"observedGeneration,omitempty"` // Generation of the spec the status is for
+	Conditions         []condition.Condition `json:"conditions,omitempty"`         // Ready, Degraded and Progressing conditions
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -62,6 +68,10 @@ type NetworkStatus struct {
 // Network is the Schema for the networks API
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type Network struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,133 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"ovn4nfv-k8s-plugin/pkg/apis/k8s/condition"
+	"ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts the network chain string to the list of hops of v1alpha2
+func (src *NetworkChaining) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha2.NetworkChaining)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec.ChainType = src.Spec.ChainType
+	dst.Spec.RoutingSpec.LeftNetwork = convertRoutingNetworksTo(src.Spec.RoutingSpec.LeftNetwork)
+	dst.Spec.RoutingSpec.RightNetwork = convertRoutingNetworksTo(src.Spec.RoutingSpec.RightNetwork)
+	dst.Spec.RoutingSpec.Hops = nil
+	if chain := strings.TrimSpace(src.Spec.RoutingSpec.NetworkChain); chain != "" {
+		// Deployments and networks alternate, the chain starts and ends
+		// with a deployment
+		items := strings.Split(chain, ",")
+		if len(items)%2 == 0 {
+			return fmt.Errorf("Network chain must start and end with a deployment: %s", chain)
+		}
+		for i := 0; i < len(items); i += 2 {
+			selector, err := metav1.ParseToLabelSelector(strings.TrimSpace(items[i]))
+			if err != nil {
+				return fmt.Errorf("Invalid deployment label %s: %v", items[i], err)
+			}
+			if len(selector.MatchExpressions) == 0 {
+				selector.MatchExpressions = nil
+			}
+			hop := v1alpha2.ChainHop{PodSelector: *selector}
+			if i > 0 {
+				hop.IngressNetwork = strings.TrimSpace(items[i-1])
+			}
+			if i < len(items)-1 {
+				hop.EgressNetwork = strings.TrimSpace(items[i+1])
+			}
+			dst.Spec.RoutingSpec.Hops = append(dst.Spec.RoutingSpec.Hops, hop)
+		}
+	}
+
+	dst.Status.State = src.Status.State
+	dst.Status.RoutesInstalled = nil
+	for _, pr := range src.Status.RoutesInstalled {
+		routes := make([]v1alpha2.Route, 0, len(pr.Routes))
+		for _, rt := range pr.Routes {
+			routes = append(routes, v1alpha2.Route(rt))
+		}
+		dst.Status.RoutesInstalled = append(dst.Status.RoutesInstalled, v1alpha2.PodRoutes{
+			Namespace:   pr.Namespace,
+			Name:        pr.Name,
+			Node:        pr.Node,
+			ContainerID: pr.ContainerID,
+			Routes:      routes,
+		})
+	}
+	dst.Status.Hops = nil
+	for _, hop := range src.Status.Hops {
+		dst.Status.Hops = append(dst.Status.Hops, v1alpha2.HopStatus(hop))
+	}
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	// Both versions share the conditions
+	dst.Status.Conditions = append([]condition.Condition(nil), src.Status.Conditions...)
+	return nil
+}
+
+// ConvertFrom converts the hops of v1alpha2 to the network chain string.
+// Only hops selecting pods with a single label can be represented.
+func (dst *NetworkChaining) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha2.NetworkChaining)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec.ChainType = src.Spec.ChainType
+	dst.Spec.RoutingSpec.LeftNetwork = convertRoutingNetworksFrom(src.Spec.RoutingSpec.LeftNetwork)
+	dst.Spec.RoutingSpec.RightNetwork = convertRoutingNetworksFrom(src.Spec.RoutingSpec.RightNetwork)
+	dst.Spec.RoutingSpec.Namespace = src.Namespace
+	var items []string
+	for i, hop := range src.Spec.RoutingSpec.Hops {
+		if len(hop.PodSelector.MatchExpressions) != 0 || len(hop.PodSelector.MatchLabels) != 1 {
+			return fmt.Errorf("Hop %d selector can not be represented in %s", i, SchemeGroupVersion.Version)
+		}
+		if i > 0 {
+			items = append(items, hop.IngressNetwork)
+		}
+		items = append(items, metav1.FormatLabelSelector(&hop.PodSelector))
+	}
+	dst.Spec.RoutingSpec.NetworkChain = strings.Join(items, ",")
+
+	dst.Status.State = src.Status.State
+	dst.Status.RoutesInstalled = nil
+	for _, pr := range src.Status.RoutesInstalled {
+		routes := make([]Route, 0, len(pr.Routes))
+		for _, rt := range pr.Routes {
+			routes = append(routes, Route(rt))
+		}
+		dst.Status.RoutesInstalled = append(dst.Status.RoutesInstalled, PodRoutes{
+			Namespace:   pr.Namespace,
+			Name:        pr.Name,
+			Node:        pr.Node,
+			ContainerID: pr.ContainerID,
+			Routes:      routes,
+		})
+	}
+	dst.Status.Hops = nil
+	for _, hop := range src.Status.Hops {
+		dst.Status.Hops = append(dst.Status.Hops, HopStatus(hop))
+	}
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	// Both versions share the conditions
+	dst.Status.Conditions = append([]condition.Condition(nil), src.Status.Conditions...)
+	return nil
+}
+
+func convertRoutingNetworksTo(networks []RoutingNetwork) []v1alpha2.RoutingNetwork {
+	var out []v1alpha2.RoutingNetwork
+	for _, n := range networks {
+		out = append(out, v1alpha2.RoutingNetwork(n))
+	}
+	return out
+}
+
+func convertRoutingNetworksFrom(networks []v1alpha2.RoutingNetwork) []RoutingNetwork {
+	var out []RoutingNetwork
+	for _, n := range networks {
+		out = append(out, RoutingNetwork(n))
+	}
+	return out
+}
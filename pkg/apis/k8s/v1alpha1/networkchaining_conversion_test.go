@@ -0,0 +1,72 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConversion(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Conversion Test Suite")
+}
+
+var _ = Describe("Test NetworkChaining conversion", func() {
+	chain := &NetworkChaining{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "chain"},
+		Spec: NetworkChainingSpec{
+			ChainType: "Routing",
+			RoutingSpec: RouteSpec{
+				LeftNetwork:  []RoutingNetwork{{NetworkName: "pnet1", GatewayIP: "172.30.10.2", Subnet: "172.30.10.0/24"}},
+				RightNetwork: []RoutingNetwork{{NetworkName: "pnet2", GatewayIP: "172.30.20.2", Subnet: "172.30.20.0/24"}},
+				NetworkChain: "app=slb,dync-net1,app=ngfw,dync-net2,app=sdwan",
+				Namespace:    "default",
+			},
+		},
+	}
+
+	It("converts the network chain to hops", func() {
+		hub := &v1alpha2.NetworkChaining{}
+		Expect(chain.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.RoutingSpec.Hops).To(Equal([]v1alpha2.ChainHop{
+			{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "slb"}}, EgressNetwork: "dync-net1"},
+			{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "ngfw"}}, IngressNetwork: "dync-net1", EgressNetwork: "dync-net2"},
+			{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "sdwan"}}, IngressNetwork: "dync-net2"},
+		}))
+		Expect(hub.Spec.RoutingSpec.LeftNetwork[0].Subnet).To(Equal("172.30.10.0/24"))
+	})
+
+	It("converts hops back to the same network chain", func() {
+		hub := &v1alpha2.NetworkChaining{}
+		Expect(chain.ConvertTo(hub)).To(Succeed())
+		back := &NetworkChaining{}
+		Expect(back.ConvertFrom(hub)).To(Succeed())
+		Expect(back.Spec).To(Equal(chain.Spec))
+	})
+
+	It("keeps the conditions", func() {
+		withStatus := chain.DeepCopy()
+		withStatus.Generation = 2
+		withStatus.Status.ObservedGeneration = 2
+		withStatus.Status.Conditions = []Condition{{Type: ConditionReady, Status: ConditionTrue, Reason: "Created", ObservedGeneration: 2}}
+		hub := &v1alpha2.NetworkChaining{}
+		Expect(withStatus.ConvertTo(hub)).To(Succeed())
+		Expect(IsConditionTrue(hub.Status.Conditions, ConditionReady)).To(BeTrue())
+		back := &NetworkChaining{}
+		Expect(back.ConvertFrom(hub)).To(Succeed())
+		Expect(back.Status.ObservedGeneration).To(Equal(int64(2)))
+		Expect(back.Status.Conditions).To(Equal(withStatus.Status.Conditions))
+	})
+
+	It("rejects selectors that can not be represented in a network chain", func() {
+		hub := &v1alpha2.NetworkChaining{}
+		hub.Spec.RoutingSpec.Hops = []v1alpha2.ChainHop{
+			{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "slb", "tier": "edge"}}},
+		}
+		Expect((&NetworkChaining{}).ConvertFrom(hub)).NotTo(Succeed())
+	})
+})
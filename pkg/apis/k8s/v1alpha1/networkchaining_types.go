@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+        "ovn4nfv-k8s-plugin/pkg/apis/k8s/condition"
+
         metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -31,7 +33,28 @@ type RouteSpec struct {
 // NetworkChainingStatus defines the observed state of NetworkChaining
 // +k8s:openapi-gen=true
 type NetworkChainingStatus struct {
-        State string `json:"state"` // Indicates if Network Chain is in "created" state
+        State              string      `json:"state"`                        // Indicates if Network Chain is in "created" state
+        RoutesInstalled    []PodRoutes `json:"routesInstalled,omitempty"`    // Routes installed in the pods of the chain
+        Hops               []HopStatus `json:"hops,omitempty"`               // Health of each hop of the chain
+        ObservedGeneration int64       `json:"observedGeneration,omitempty"` // Generation of the spec the status is for
+        Conditions         []condition.Condition `json:"conditions,omitempty"`         // Ready, Degraded and Progressing conditions
+}
+
+// PodRoutes are the routes installed in a pod by the chain
+type PodRoutes struct {
+        Namespace   string  `json:"namespace"`
+        Name        string  `json:"name"`
+        Node        string  `json:"node"`
+        ContainerID string  `json:"containerID,omitempty"` // Routes are installed again when the container changes
+        Routes      []Route `json:"routes"`
+}
+
+// HopStatus is the health of a hop of the chain
+type HopStatus struct {
+        Deployment string `json:"deployment"`        // Label selector of the hop
+        Pods       []string `json:"pods,omitempty"`    // Ready pods the traffic is load-balanced across
+        Healthy    bool     `json:"healthy"`           // Routes of at least one pod of the hop are installed
+        Message    string   `json:"message,omitempty"` // Reason a pod of the hop is left out
 }
 
 
@@ -39,6 +62,10 @@ type NetworkChainingStatus struct {
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=networkchainings,scope=Namespaced
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 type NetworkChaining struct {
@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	"ovn4nfv-k8s-plugin/pkg/apis/k8s/condition"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -43,7 +45,10 @@ type ProviderNetworkStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
-	State string `json:"state"` // Indicates if ProviderNetwork is in "created" state
+	State              string                `json:"state"`                        // Indicates if ProviderNetwork is in "created" state
+	Node               string                `json:"node,omitempty"`               // Node selected when the node selector is "any"
+	ObservedGeneration int64                 `json:"observedGeneration,omitempty"` // Generation of the spec the status is for
+	Conditions         []condition.Condition `json:"conditions,omitempty"`         // Ready, Degraded and Progressing conditions
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -51,6 +56,10 @@ type ProviderNetworkStatus struct {
 // ProviderNetwork is the Schema for the providernetworks API
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +genclient
 type ProviderNetwork struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -0,0 +1,4 @@
+// Package v1alpha2 contains API Schema definitions for the k8s v1alpha2 API group
+// +k8s:deepcopy-gen=package,register
+// +groupName=k8s.plugin.opnfv.org
+package v1alpha2
@@ -0,0 +1,121 @@
+package v1alpha2
+
+import (
+	"ovn4nfv-k8s-plugin/pkg/apis/k8s/condition"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Created indicates that the routes of all the hops are installed
+	Created = "Created"
+	// CreateInternalError indicates that the routes could not be installed
+	CreateInternalError = "CreateInternalError"
+	// Degraded indicates that the routes of some hops are not installed
+	Degraded = "Degraded"
+)
+
+const (
+	// RoutingChain steers the traffic with routes installed in the pods
+	RoutingChain = "Routing"
+	// RouterPolicyChain steers the traffic with policies of the cluster
+	// router, the edge networks must be connected to the router
+	RouterPolicyChain = "RouterPolicy"
+)
+
+// NetworkChainingSpec defines the desired state of NetworkChaining
+type NetworkChainingSpec struct {
+	ChainType   string    `json:"chainType"`   // Routing or RouterPolicy
+	RoutingSpec RouteSpec `json:"routingSpec"` // Spec for Routing type
+	// Add other Chanining mechanisms here
+}
+
+// RouteSpec is the chain of hops between the left and right networks
+type RouteSpec struct {
+	LeftNetwork  []RoutingNetwork `json:"leftNetwork"`  // Networks on the left side of the chain
+	RightNetwork []RoutingNetwork `json:"rightNetwork"` // Networks on the right side of the chain
+	Hops         []ChainHop       `json:"hops"`         // Hops from left to right
+}
+
+// ChainHop is a set of pods the traffic of the chain goes through
+type ChainHop struct {
+	PodSelector metav1.LabelSelector `json:"podSelector"` // Pods of the hop in the chain namespace
+	// Network shared with the previous hop, empty for the first hop which
+	// is attached to the left networks
+	IngressNetwork string `json:"ingressNetwork,omitempty"`
+	// Network shared with the next hop, empty for the last hop which is
+	// attached to the right networks
+	EgressNetwork string `json:"egressNetwork,omitempty"`
+}
+
+// RoutingNetwork is a network at an edge of the chain
+type RoutingNetwork struct {
+	NetworkName string `json:"networkName"` // Name of the network
+	GatewayIP   string `json:"gatewayIp"`   // Gateway IP Address
+	Subnet      string `json:"subnet"`      // Subnet
+}
+
+// Route is a route installed in a pod
+type Route struct {
+	Dst string `json:"dst"`
+	GW  string `json:"gw,omitempty"`
+}
+
+// NetworkChainingStatus defines the observed state of NetworkChaining
+type NetworkChainingStatus struct {
+	State              string                `json:"state"`                        // Indicates if Network Chain is in "created" state
+	RoutesInstalled    []PodRoutes           `json:"routesInstalled,omitempty"`    // Routes installed in the pods of the chain
+	Hops               []HopStatus           `json:"hops,omitempty"`               // Health of each hop of the chain
+	ObservedGeneration int64                 `json:"observedGeneration,omitempty"` // Generation of the spec the status is for
+	Conditions         []condition.Condition `json:"conditions,omitempty"`         // Ready, Degraded and Progressing conditions
+}
+
+// PodRoutes are the routes installed in a pod by the chain
+type PodRoutes struct {
+	Namespace   string  `json:"namespace"`
+	Name        string  `json:"name"`
+	Node        string  `json:"node"`
+	ContainerID string  `json:"containerID,omitempty"` // Routes are installed again when the container changes
+	Routes      []Route `json:"routes"`
+}
+
+// HopStatus is the health of a hop of the chain
+type HopStatus struct {
+	Deployment string   `json:"deployment"`        // Label selector of the hop
+	Pods       []string `json:"pods,omitempty"`    // Ready pods the traffic is load-balanced across
+	Healthy    bool     `json:"healthy"`           // Routes of at least one pod of the hop are installed
+	Message    string   `json:"message,omitempty"` // Reason a pod of the hop is left out
+}
+
+// NetworkChaining is the Schema for the networkchainings API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=networkchainings,scope=Namespaced
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type NetworkChaining struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NetworkChainingSpec   `json:"spec,omitempty"`
+	Status NetworkChainingStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkChainingList contains a list of NetworkChaining
+type NetworkChainingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NetworkChaining `json:"items"`
+}
+
+// Hub marks v1alpha2 as the version the other versions are converted to
+func (*NetworkChaining) Hub() {}
+
+func init() {
+	SchemeBuilder.Register(&NetworkChaining{}, &NetworkChainingList{})
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"ovn4nfv-k8s-plugin/internal/pkg/netattachdef"
 	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
 	"ovn4nfv-k8s-plugin/pkg/utils"
 	"reflect"
@@ -29,7 +32,7 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileNetwork{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileNetwork{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor("network-controller")}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -55,8 +58,9 @@ var _ reconcile.Reconciler = &ReconcileNetwork{}
 type ReconcileNetwork struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
 }
 type reconcileFun func(instance *k8sv1alpha1.Network, reqLogger logr.Logger) error
 
@@ -113,19 +117,33 @@ func (r *ReconcileNetwork) createNetwork(cr *k8sv1alpha1.Network, reqLogger logr
 		if err != nil && !reflect.DeepEqual(err, fmt.Errorf("LS exists")) {
 			// Log the error
 			reqLogger.Error(err, "Error Creating Network")
+			r.recorder.Eventf(cr, corev1.EventTypeWarning, "CreateNetworkFailed", "Failed to create network: %v", err)
 			cr.Status.State = k8sv1alpha1.CreateInternalError
+			setReady(cr, k8sv1alpha1.ConditionFalse, "CreateFailed", err.Error())
 		} else {
 			cr.Status.State = k8sv1alpha1.Created
+			setReady(cr, k8sv1alpha1.ConditionTrue, "Created", "Logical switch created")
 		}
+		cr.Status.ObservedGeneration = cr.Generation
 		err = r.client.Status().Update(context.TODO(), cr)
 		if err != nil {
 			return err
 		}
-		// If OVN internal error don't requeue
+		if cr.Status.State != k8sv1alpha1.Created {
+			// If OVN internal error don't requeue
+			return nil
+		}
+		err = netattachdef.Sync(context.TODO(), r.client, r.scheme, cr, cr.Spec.CniType, netattachdef.Namespaces)
+		if err != nil {
+			reqLogger.Error(err, "Error Syncing NetworkAttachmentDefinitions")
+			r.recorder.Eventf(cr, corev1.EventTypeWarning, "SyncNetAttachDefFailed", "Failed to sync NetworkAttachmentDefinitions: %v", err)
+			return err
+		}
 		return nil
 		// Add other CNI types here
 	}
 	reqLogger.Info("CNI type not supported", "name", cr.Spec.CniType)
+	r.recorder.Eventf(cr, corev1.EventTypeWarning, "UnsupportedCNIType", "CNI type %s not supported", cr.Spec.CniType)
 	return fmt.Errorf("CNI type not supported")
 
 }
@@ -142,7 +160,9 @@ func (r *ReconcileNetwork) deleteNetwork(cr *k8sv1alpha1.Network, reqLogger logr
 		if err != nil {
 			// Log the error
 			reqLogger.Error(err, "Error Delete Network")
+			r.recorder.Eventf(cr, corev1.EventTypeWarning, "DeleteNetworkFailed", "Failed to delete network: %v", err)
 			cr.Status.State = k8sv1alpha1.DeleteInternalError
+			setReady(cr, k8sv1alpha1.ConditionFalse, "DeleteFailed", err.Error())
 			err = r.client.Status().Update(context.TODO(), cr)
 			if err != nil {
 				return err
@@ -156,12 +176,31 @@ func (r *ReconcileNetwork) deleteNetwork(cr *k8sv1alpha1.Network, reqLogger logr
 	return fmt.Errorf("CNI type not supported")
 }
 
+// setReady sets the Ready condition of the network. The logical switch is
+// created at once so the network is never progressing nor degraded.
+func setReady(cr *k8sv1alpha1.Network, status k8sv1alpha1.ConditionStatus, reason, message string) {
+	for _, c := range []k8sv1alpha1.Condition{
+		{Type: k8sv1alpha1.ConditionReady, Status: status, Reason: reason, Message: message},
+		{Type: k8sv1alpha1.ConditionProgressing, Status: k8sv1alpha1.ConditionFalse, Reason: reason},
+		{Type: k8sv1alpha1.ConditionDegraded, Status: k8sv1alpha1.ConditionFalse, Reason: reason},
+	} {
+		c.ObservedGeneration = cr.Generation
+		k8sv1alpha1.SetCondition(&cr.Status.Conditions, c)
+	}
+}
+
 func (r *ReconcileNetwork) reconcileFinalizers(instance *k8sv1alpha1.Network, reqLogger logr.Logger) (err error) {
 
 	if !instance.DeletionTimestamp.IsZero() {
 		// Instance marked for deletion
 		if utils.Contains(instance.ObjectMeta.Finalizers, nfnNetworkFinalizer) {
 			reqLogger.V(1).Info("Finalizer found - delete network")
+			// Keep the finalizer till the definitions are gone, pods must
+			// not select the network once deleted
+			if err = netattachdef.Delete(context.TODO(), r.client, r.scheme, instance, netattachdef.Namespaces); err != nil {
+				reqLogger.Error(err, "Error Deleting NetworkAttachmentDefinitions")
+				return err
+			}
 			if err = r.deleteNetwork(instance, reqLogger); err != nil {
 				reqLogger.Error(err, "Delete network")
 			}
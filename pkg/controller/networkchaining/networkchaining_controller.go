@@ -19,14 +19,24 @@ package networkchaining
 import (
 	"fmt"
 	"context"
+	"strings"
+	"time"
 	notif "ovn4nfv-k8s-plugin/internal/pkg/nfnNotify"
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
 	chaining "ovn4nfv-k8s-plugin/internal/pkg/utils"
-	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+	"ovn4nfv-k8s-plugin/pkg/apis/k8s/condition"
+	k8sv1alpha2 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha2"
 	"ovn4nfv-k8s-plugin/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"github.com/go-logr/logr"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -44,12 +54,17 @@ func Add(mgr manager.Manager) error {
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileNetworkChaining{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager) *ReconcileNetworkChaining {
+	return &ReconcileNetworkChaining{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor("networkchaining-controller"),
+		failures: newRouteFailures()}
 }
 
+// routeRetryDelay is the delay before the routes an agent failed to add are
+// sent again
+const routeRetryDelay = 10 * time.Second
+
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r *ReconcileNetworkChaining) error {
 	// Create a new controller
 	c, err := controller.New("networkchaining-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -57,13 +72,67 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	}
 
 	// Watch for changes to primary resource NetworkChaining
-	err = c.Watch(&source.Kind{Type: &k8sv1alpha1.NetworkChaining{}}, &handler.EnqueueRequestForObject{})
+	err = c.Watch(&source.Kind{Type: &k8sv1alpha2.NetworkChaining{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the pods of the chains
+	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			return chainsForPod(mgr.GetClient(), o.Meta)
+		}),
+	})
 	if err != nil {
 		return err
 	}
+
+	// Send again the routes the agents failed to add
+	retries := make(chan event.GenericEvent)
+	err = c.Watch(&source.Channel{Source: retries}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+	notif.SetRouteFailureHandler(func(chain, podNamespace, podName string) {
+		r.failures.add(chain, podNamespace, podName)
+		namespace, name := chain, ""
+		if i := strings.Index(chain, "/"); i >= 0 {
+			namespace, name = chain[:i], chain[i+1:]
+		}
+		cr := &k8sv1alpha2.NetworkChaining{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+		time.AfterFunc(routeRetryDelay, func() {
+			retries <- event.GenericEvent{Meta: cr, Object: cr}
+		})
+	})
 	return nil
 }
 
+// chainsForPod returns the chains with a hop selecting the pod
+func chainsForPod(c client.Client, pod metav1.Object) []reconcile.Request {
+	chains := &k8sv1alpha2.NetworkChainingList{}
+	if err := c.List(context.TODO(), chains, client.InNamespace(pod.GetNamespace())); err != nil {
+		log.Error(err, "Failed to list network chains", "namespace", pod.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, chain := range chains.Items {
+		selectors, _, err := chaining.ParseNetworkChain(chain.Spec.RoutingSpec)
+		if err != nil {
+			continue
+		}
+		for _, selector := range selectors {
+			if !selector.Matches(labels.Set(pod.GetLabels())) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: chain.Namespace, Name: chain.Name},
+			})
+			break
+		}
+	}
+	return requests
+}
+
 // blank assignment to verify that ReconcileNetworkChaining implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileNetworkChaining{}
 
@@ -71,10 +140,12 @@ var _ reconcile.Reconciler = &ReconcileNetworkChaining{}
 type ReconcileNetworkChaining struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	failures *routeFailures
 }
-type reconcileFun func(instance *k8sv1alpha1.NetworkChaining, reqLogger logr.Logger) error
+type reconcileFun func(instance *k8sv1alpha2.NetworkChaining, reqLogger logr.Logger) error
 // Reconcile reads that state of the cluster for a NetworkChaining object and makes changes based on the state read
 // and what is in the NetworkChaining.Spec
 // TODO(user): Modify this Reconcile function to implement your Controller logic.  This example creates
@@ -87,7 +158,7 @@ func (r *ReconcileNetworkChaining) Reconcile(request reconcile.Request) (reconci
 	reqLogger.Info("Reconciling NetworkChaining")
 
 	// Fetch the NetworkChaining instance
-	instance := &k8sv1alpha1.NetworkChaining{}
+	instance := &k8sv1alpha2.NetworkChaining{}
 	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -115,44 +186,182 @@ const (
 	nfnNetworkChainFinalizer = "nfnCleanUpNetworkChain"
 )
 
-func (r *ReconcileNetworkChaining) createChain(cr *k8sv1alpha1.NetworkChaining, reqLogger logr.Logger) error {
+func (r *ReconcileNetworkChaining) createChain(cr *k8sv1alpha2.NetworkChaining, reqLogger logr.Logger) error {
 
 	if !cr.DeletionTimestamp.IsZero() {
 		// Marked for deletion
 		return nil
 	}
-	switch {
-	case cr.Spec.ChainType == "Routing":
-		routeList, err := chaining.CalculateRoutes(cr)
-		if err != nil {
-			return err
+	var hops []k8sv1alpha2.HopStatus
+	var err error
+	switch cr.Spec.ChainType {
+	case k8sv1alpha2.RoutingChain:
+		hops, err = r.createRoutingChain(cr, reqLogger)
+	case k8sv1alpha2.RouterPolicyChain:
+		hops, err = r.createPolicyChain(cr, reqLogger)
+	// Add other Chaining types here
+	default:
+		reqLogger.Info("Chaining type not supported", "name", cr.Spec.ChainType)
+		r.recorder.Eventf(cr, corev1.EventTypeWarning, "UnsupportedChainType", "Chaining type %s not supported", cr.Spec.ChainType)
+		return fmt.Errorf("Chaining type not supported")
+	}
+	if hops == nil && err != nil {
+		r.recorder.Eventf(cr, corev1.EventTypeWarning, "CreateChainFailed", "Failed to create chain: %v", err)
+		if cr.Status.ObservedGeneration != cr.Generation || !condition.IsConditionTrue(cr.Status.Conditions, condition.ConditionProgressing) {
+			// Report the chain is not applied yet while retrying
+			cr.Status.ObservedGeneration = cr.Generation
+			setConditions(cr, condition.ConditionFalse, "Retrying", err.Error(),
+				condition.ConditionTrue, condition.ConditionFalse, "")
+			if updateErr := r.client.Status().Update(context.TODO(), cr); updateErr != nil {
+				return updateErr
+			}
 		}
-		err = notif.SendRouteNotif(routeList, "create")
-		if err != nil {
-			cr.Status.State = k8sv1alpha1.CreateInternalError
-			reqLogger.Error(err, "Error Sending Message")
+		return err
+	}
+
+	cr.Status.Hops = hops
+	cr.Status.ObservedGeneration = cr.Generation
+	if err != nil {
+		r.recorder.Eventf(cr, corev1.EventTypeWarning, "CreateChainFailed", "Failed to create chain: %v", err)
+		cr.Status.State = k8sv1alpha2.CreateInternalError
+		setConditions(cr, condition.ConditionFalse, "CreateFailed", err.Error(),
+			condition.ConditionTrue, condition.ConditionFalse, "")
+	} else {
+		cr.Status.State = k8sv1alpha2.Created
+		var unhealthy []string
+		for _, hop := range hops {
+			if !hop.Healthy {
+				r.recorder.Eventf(cr, corev1.EventTypeWarning, "HopUnhealthy", "Hop %s has no healthy pod: %s", hop.Deployment, hop.Message)
+				cr.Status.State = k8sv1alpha2.Degraded
+				unhealthy = append(unhealthy, fmt.Sprintf("%s: %s", hop.Deployment, hop.Message))
+			}
+		}
+		if len(unhealthy) > 0 {
+			message := fmt.Sprintf("Hops without healthy pod: %s", strings.Join(unhealthy, "; "))
+			setConditions(cr, condition.ConditionFalse, "HopUnhealthy", message,
+				condition.ConditionFalse, condition.ConditionTrue, message)
 		} else {
-			cr.Status.State = k8sv1alpha1.Created
+			setConditions(cr, condition.ConditionTrue, "Created", fmt.Sprintf("Chain of %d hops applied", len(hops)),
+				condition.ConditionFalse, condition.ConditionFalse, "")
 		}
+	}
+	if updateErr := r.client.Status().Update(context.TODO(), cr); updateErr != nil {
+		return updateErr
+	}
+	// Retry applying the changes
+	return err
+}
 
-		err = r.client.Status().Update(context.TODO(), cr)
-		if err != nil {
-			return err
-		}
-		return nil
-	// Add other Chaining types here
+// createRoutingChain installs the routes of the chain in the pods
+func (r *ReconcileNetworkChaining) createRoutingChain(cr *k8sv1alpha2.NetworkChaining, reqLogger logr.Logger) ([]k8sv1alpha2.HopStatus, error) {
+	routeList, hops, err := chaining.CalculateRoutes(r.client, cr)
+	if err != nil {
+		return nil, err
+	}
+	// The chain type may have changed
+	if err = ovn.DeleteChainPolicies(chainName(cr)); err != nil {
+		return hops, err
+	}
+	if err = r.sendRoutes(cr, routeList, reqLogger); err != nil {
+		return hops, err
 	}
-	reqLogger.Info("Chaining type not supported", "name", cr.Spec.ChainType)
-	return fmt.Errorf("Chaining type not supported")
+	return hops, nil
 }
 
-func (r *ReconcileNetworkChaining) deleteChain(cr *k8sv1alpha1.NetworkChaining, reqLogger logr.Logger) error {
+// sendRoutes sends the changes from the routes installed in the pods by the
+// chain, the pods the agents failed to add the routes to get all their routes
+// again
+func (r *ReconcileNetworkChaining) sendRoutes(cr *k8sv1alpha2.NetworkChaining, routeList []chaining.RoutingInfo, reqLogger logr.Logger) error {
+	installed, failed := r.failures.installed(chainName(cr), cr.Status.RoutesInstalled)
+	remove, insert := chaining.RouteDelta(installed, routeList)
+	err := notif.SendRouteNotif(chainName(cr), remove, "delete")
+	if err == nil {
+		err = notif.SendRouteNotif(chainName(cr), insert, "create")
+	}
+	if err != nil {
+		reqLogger.Error(err, "Error Sending Message")
+		return err
+	}
+	r.failures.clear(chainName(cr), failed)
+	// Remember the routes so that they can be removed with the chain
+	cr.Status.RoutesInstalled = chaining.PodRoutes(routeList)
+	return nil
+}
 
-	reqLogger.Info("Delete Chain not implementated")
-	return fmt.Errorf("Not Implemented")
+// createPolicyChain steers the traffic of the chain with policies of the
+// cluster router, the pods of the hops route the traffic back to the router
+func (r *ReconcileNetworkChaining) createPolicyChain(cr *k8sv1alpha2.NetworkChaining, reqLogger logr.Logger) ([]k8sv1alpha2.HopStatus, error) {
+	policies, routeList, hops, err := chaining.CalculatePolicies(r.client, cr)
+	if err != nil {
+		return nil, err
+	}
+	// The routes of a routing chain are removed when the chain type changed
+	if err = r.sendRoutes(cr, routeList, reqLogger); err != nil {
+		return hops, err
+	}
+	// Without policies while a hop is unhealthy the previous ones are
+	// removed
+	if err = ovn.SetChainPolicies(chainName(cr), policies); err != nil {
+		reqLogger.Error(err, "Error setting router policies")
+		return hops, err
+	}
+	return hops, nil
 }
 
-func (r *ReconcileNetworkChaining) reconcileFinalizers(instance *k8sv1alpha1.NetworkChaining, reqLogger logr.Logger) (err error) {
+// setConditions sets the Ready condition with the reason and message, and
+// the Progressing and Degraded conditions of the chain
+func setConditions(cr *k8sv1alpha2.NetworkChaining, ready condition.ConditionStatus, reason, message string,
+	progressing, degraded condition.ConditionStatus, degradedMessage string) {
+	for _, c := range []condition.Condition{
+		{Type: condition.ConditionReady, Status: ready, Reason: reason, Message: message},
+		{Type: condition.ConditionProgressing, Status: progressing, Reason: reason},
+		{Type: condition.ConditionDegraded, Status: degraded, Reason: reason, Message: degradedMessage},
+	} {
+		c.ObservedGeneration = cr.Generation
+		condition.SetCondition(&cr.Status.Conditions, c)
+	}
+}
+
+func chainName(cr *k8sv1alpha2.NetworkChaining) string {
+	return cr.Namespace + "/" + cr.Name
+}
+
+func (r *ReconcileNetworkChaining) deleteChain(cr *k8sv1alpha2.NetworkChaining, reqLogger logr.Logger) error {
+	if err := ovn.DeleteChainPolicies(chainName(cr)); err != nil {
+		return err
+	}
+	return r.deleteRoutes(cr, reqLogger)
+}
+
+// deleteRoutes removes the routes installed in the pods by the chain. The
+// routes of the pods gone went away with them.
+func (r *ReconcileNetworkChaining) deleteRoutes(cr *k8sv1alpha2.NetworkChaining, reqLogger logr.Logger) error {
+	var routeList []chaining.RoutingInfo
+	for _, pr := range cr.Status.RoutesInstalled {
+		err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: pr.Namespace, Name: pr.Name}, &corev1.Pod{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		routeList = append(routeList, chaining.RoutingInfo{
+			Namespace:            pr.Namespace,
+			Name:                 pr.Name,
+			Node:                 pr.Node,
+			DynamicNetworkRoutes: pr.Routes,
+			LeaveChain:           true,
+		})
+	}
+	reqLogger.Info("Removing chain routes", "pods", len(routeList))
+	if err := notif.SendRouteNotif(chainName(cr), routeList, "delete"); err != nil {
+		return err
+	}
+	r.failures.forget(chainName(cr))
+	return nil
+}
+
+func (r *ReconcileNetworkChaining) reconcileFinalizers(instance *k8sv1alpha2.NetworkChaining, reqLogger logr.Logger) (err error) {
 
 	if !instance.DeletionTimestamp.IsZero() {
 		// Instance marked for deletion
@@ -160,8 +369,11 @@ func (r *ReconcileNetworkChaining) reconcileFinalizers(instance *k8sv1alpha1.Net
 			reqLogger.V(1).Info("Finalizer found - delete chain")
 			if err = r.deleteChain(instance, reqLogger); err != nil {
 				reqLogger.Error(err, "Delete chain")
+				r.recorder.Eventf(instance, corev1.EventTypeWarning, "DeleteChainFailed", "Failed to delete chain: %v", err)
+				// Keep the finalizer till the routes are removed from
+				// the pods, the agent of a node may be reconnecting
+				return err
 			}
-			// Remove the finalizer even if Delete Network fails. Fatal error retry will not resolve
 			instance.ObjectMeta.Finalizers = utils.Remove(instance.ObjectMeta.Finalizers, nfnNetworkChainFinalizer)
 			if err = r.client.Update(context.TODO(), instance); err != nil {
 				reqLogger.Error(err, "Removing Finalizer")
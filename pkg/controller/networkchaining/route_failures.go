@@ -0,0 +1,87 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package networkchaining
+
+import (
+	"sync"
+
+	k8sv1alpha2 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha2"
+)
+
+// routeFailures are the pods of each chain the agents failed to add the
+// routes to. The routes of these pods are not installed whatever the status
+// of the chain records.
+type routeFailures struct {
+	mu   sync.Mutex
+	pods map[string]map[string]bool
+}
+
+func newRouteFailures() *routeFailures {
+	return &routeFailures{pods: make(map[string]map[string]bool)}
+}
+
+// add records that the routes of the chain were not added to the pod
+func (f *routeFailures) add(chain, namespace, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pods[chain] == nil {
+		f.pods[chain] = make(map[string]bool)
+	}
+	f.pods[chain][namespace+"/"+name] = true
+}
+
+// installed returns the routes installed by the chain without the pods the
+// routes failed to be added to, and these pods
+func (f *routeFailures) installed(chain string, routes []k8sv1alpha2.PodRoutes) ([]k8sv1alpha2.PodRoutes, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	failed := f.pods[chain]
+	if len(failed) == 0 {
+		return routes, nil
+	}
+	var installed []k8sv1alpha2.PodRoutes
+	for _, pr := range routes {
+		if !failed[pr.Namespace+"/"+pr.Name] {
+			installed = append(installed, pr)
+		}
+	}
+	var pods []string
+	for pod := range failed {
+		pods = append(pods, pod)
+	}
+	return installed, pods
+}
+
+// clear forgets the failures of the pods of the chain once their routes are
+// sent again
+func (f *routeFailures) clear(chain string, pods []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, pod := range pods {
+		delete(f.pods[chain], pod)
+	}
+	if len(f.pods[chain]) == 0 {
+		delete(f.pods, chain)
+	}
+}
+
+// forget forgets the failures of the deleted chain
+func (f *routeFailures) forget(chain string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.pods, chain)
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"ovn4nfv-k8s-plugin/internal/pkg/netattachdef"
+	notif "ovn4nfv-k8s-plugin/internal/pkg/nfnNotify"
 	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
+	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -24,14 +29,9 @@ import (
 var log = logf.Log.WithName("controller_pod")
 
 const (
-	nfnNetworkAnnotation = "k8s.plugin.opnfv.org/nfn-network"
+	nfnNetworkAnnotation = podnetwork.NetworkAnnotation
 )
 
-type nfnNetwork struct {
-	Type      string                   "json:\"type\""
-	Interface []map[string]interface{} "json:\"interface\""
-}
-
 var enableOvnDefaultIntf bool = true
 
 // Add creates a new Pod Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -42,7 +42,7 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcilePod{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcilePod{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor("pod-controller")}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -58,13 +58,16 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	p := predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			annotaion := e.MetaNew.GetAnnotations()
-			// The object doesn't contain annotation ,nfnNetworkAnnotation so the event will be
-			// ignored.
-			if _, ok := annotaion[nfnNetworkAnnotation]; !ok {
-				return false
-			}
-			// If pod is already processed by OVN don't add event
+			// Interfaces of a pod already processed by OVN follow the
+			// changes of its network annotations
 			if _, ok := annotaion[ovn.Ovn4nfvAnnotationTag]; ok {
+				return networkAnnotationsChanged(e.MetaOld.GetAnnotations(), annotaion)
+			}
+			// The object doesn't contain annotation ,nfnNetworkAnnotation or the
+			// networks annotation so the event will be ignored.
+			_, nfnOk := annotaion[nfnNetworkAnnotation]
+			_, networksOk := annotaion[podnetwork.NetworksAnnotation]
+			if !nfnOk && !networksOk {
 				return false
 			}
 			return true
@@ -104,8 +107,9 @@ var _ reconcile.Reconciler = &ReconcilePod{}
 type ReconcilePod struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
 }
 
 // Reconcile function
@@ -145,6 +149,16 @@ func (r *ReconcilePod) Reconcile(request reconcile.Request) (reconcile.Result, e
 		}, nil
 	}
 
+	if _, ok := instance.Annotations[ovn.Ovn4nfvAnnotationTag]; ok {
+		err = r.updateLogicalPorts(instance)
+		if err != nil {
+			// Requeue the object
+			return reconcile.Result{}, err
+		}
+		reqLogger.Info("Exit Reconciling Pod")
+		return reconcile.Result{}, nil
+	}
+
 	err = r.addLogicalPorts(instance)
 	if err != nil && err.Error() == "Failed to add ports" {
 		// Requeue the object
@@ -154,13 +168,20 @@ func (r *ReconcilePod) Reconcile(request reconcile.Request) (reconcile.Result, e
 	return reconcile.Result{}, nil
 }
 
-// annotatePod annotates pod with the given annotations
-func (r *ReconcilePod) setPodAnnotation(pod *corev1.Pod, key, value string) error {
+// setPodAnnotations annotates pod with the given annotations
+func (r *ReconcilePod) setPodAnnotations(pod *corev1.Pod, annotations map[string]string) error {
 
-	patchData := fmt.Sprintf(`{"metadata":{"annotations":{"%s":"%s"}}}`, key, value)
-	err := r.client.Patch(context.TODO(), pod, client.ConstantPatch(types.MergePatchType, []byte(patchData)))
+	patchData, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	err = r.client.Patch(context.TODO(), pod, client.ConstantPatch(types.MergePatchType, patchData))
 	if err != nil {
-		log.Error(err, "Updating pod failed", "pod", pod, "key", key, "value", value)
+		log.Error(err, "Updating pod failed", "pod", pod, "annotations", annotations)
 		return err
 	}
 	return nil
@@ -170,17 +191,21 @@ func (r *ReconcilePod) addLogicalPorts(pod *corev1.Pod) error {
 
 	nfn, err := r.readPodAnnotation(pod)
 	if err != nil {
+		if _, ok := pod.Annotations[nfnNetworkAnnotation]; ok {
+			r.recorder.Eventf(pod, corev1.EventTypeWarning, "InvalidNetworkAnnotation",
+				"Invalid %s annotation, only the default interface is added: %v", nfnNetworkAnnotation, err)
+		}
 		// No annotation for multiple interfaces
-		nfn = &nfnNetwork{Interface: nil}
+		nfn = &podnetwork.NetworkRequest{Interface: nil}
 		if enableOvnDefaultIntf == true {
-			nfn.Type = "ovn4nfv"
+			nfn.Type = podnetwork.OvnType
 		} else {
 			return err
 		}
 	}
 
 	switch {
-	case nfn.Type == "ovn4nfv":
+	case nfn.Type == podnetwork.OvnType:
 		ovnCtl, err := ovn.GetOvnController()
 		if err != nil {
 			return err
@@ -188,17 +213,79 @@ func (r *ReconcilePod) addLogicalPorts(pod *corev1.Pod) error {
 		if _, ok := pod.Annotations[ovn.Ovn4nfvAnnotationTag]; ok {
 			return fmt.Errorf("Pod annotation found")
 		}
-		key, value := ovnCtl.AddLogicalPorts(pod, nfn.Interface)
-		if len(key) > 0 {
-			return r.setPodAnnotation(pod, key, value)
+		interfaces, err := r.selectedInterfaces(pod, nfn.Interface)
+		if err != nil {
+			return err
 		}
-		return fmt.Errorf("Failed to add ports")
+		annotations, err := ovnCtl.AddLogicalPorts(pod, append(nfn.Interface, interfaces...))
+		if err != nil {
+			r.recorder.Eventf(pod, corev1.EventTypeWarning, "AddLogicalPortsFailed", "Failed to add ports: %v", err)
+			return fmt.Errorf("Failed to add ports")
+		}
+		if len(annotations) > 0 {
+			return r.setPodAnnotations(pod, annotations)
+		}
+		return nil
 	default:
+		r.recorder.Eventf(pod, corev1.EventTypeWarning, "UnsupportedNetworkType", "Unsupported Networking type %s", nfn.Type)
 		return fmt.Errorf("Unsupported Networking type %s", nfn.Type)
 		// Add other types here
 	}
 }
 
+// updateLogicalPorts hot-plugs the interfaces added to the network
+// annotations of a pod already set up and unplugs the interfaces removed
+func (r *ReconcilePod) updateLogicalPorts(pod *corev1.Pod) error {
+	if pod.DeletionTimestamp != nil {
+		return nil
+	}
+	nfn := &podnetwork.NetworkRequest{Type: podnetwork.OvnType}
+	if _, ok := pod.Annotations[nfnNetworkAnnotation]; ok {
+		var err error
+		nfn, err = r.readPodAnnotation(pod)
+		if err != nil {
+			r.recorder.Eventf(pod, corev1.EventTypeWarning, "InvalidNetworkAnnotation",
+				"Invalid %s annotation, the interfaces are not updated: %v", nfnNetworkAnnotation, err)
+			return nil
+		}
+	}
+	if nfn.Type != podnetwork.OvnType {
+		return nil
+	}
+	ovnCtl, err := ovn.GetOvnController()
+	if err != nil {
+		return err
+	}
+	interfaces, err := r.selectedInterfaces(pod, nfn.Interface)
+	if err != nil {
+		return err
+	}
+	annotations, added, removed, err := ovnCtl.UpdateLogicalPorts(pod, append(nfn.Interface, interfaces...))
+	if err != nil {
+		r.recorder.Eventf(pod, corev1.EventTypeWarning, "UpdateLogicalPortsFailed", "Failed to update ports: %v", err)
+		return err
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	// The pod is annotated once the agent is notified, the ports are
+	// updated again and the agent notified again on retry
+	if err := notif.SendPodInterfaceNotif(pod, added, removed); err != nil {
+		r.recorder.Eventf(pod, corev1.EventTypeWarning, "NotifyAgentFailed",
+			"Failed to notify the agent of node %s, retrying: %v", pod.Spec.NodeName, err)
+		return err
+	}
+	if err := r.setPodAnnotations(pod, annotations); err != nil {
+		return err
+	}
+	var names []string
+	for _, intf := range added {
+		names = append(names, intf.Interface)
+	}
+	r.recorder.Eventf(pod, corev1.EventTypeNormal, "InterfacesUpdated", "Interfaces added %v, removed %v", names, removed)
+	return nil
+}
+
 func (r *ReconcilePod) deleteLogicalPorts(name, namesapce string) error {
 
 	// Run delete for all controllers; pod annonations inaccessible
@@ -212,16 +299,115 @@ func (r *ReconcilePod) deleteLogicalPorts(name, namesapce string) error {
 	// Add other types here
 }
 
-func (r *ReconcilePod) readPodAnnotation(pod *corev1.Pod) (*nfnNetwork, error) {
+// selectedInterfaces returns the interfaces of the elements of the networks
+// annotation selecting NetworkAttachmentDefinitions of ovn4nfv, or ovn4nfv
+// networks when there is no definition. The other elements are left to the
+// CNI they select.
+func (r *ReconcilePod) selectedInterfaces(pod *corev1.Pod, requested []podnetwork.InterfaceRequest) ([]podnetwork.InterfaceRequest, error) {
+	annotation, ok := pod.Annotations[podnetwork.NetworksAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	elements, err := podnetwork.ParseNetworkSelection(annotation)
+	if err != nil {
+		r.recorder.Eventf(pod, corev1.EventTypeWarning, "InvalidNetworkAnnotation", "Invalid %s annotation: %v",
+			podnetwork.NetworksAnnotation, err)
+		return nil, nil
+	}
+	used := make(map[string]bool)
+	for _, intf := range requested {
+		used[intf.Interface] = true
+	}
+	var interfaces []podnetwork.InterfaceRequest
+	for i, element := range elements {
+		namespace := element.Namespace
+		if namespace == "" {
+			namespace = pod.Namespace
+		}
+		// The elements name NetworkAttachmentDefinitions, or the networks
+		// themselves in the clusters without definitions
+		network, isDefinition, err := netattachdef.Network(context.TODO(), r.client, namespace, element.Name)
+		if err != nil {
+			return nil, err
+		}
+		if isDefinition && network == "" {
+			// Definition of another CNI plugin
+			continue
+		}
+		if !isDefinition {
+			found, err := r.ovnNetworkExists(namespace, element.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+		}
+		intf, err := element.ToInterfaceRequest(i)
+		if err != nil {
+			r.recorder.Eventf(pod, corev1.EventTypeWarning, "InvalidNetworkAnnotation", "Invalid %s annotation: %v",
+				podnetwork.NetworksAnnotation, err)
+			continue
+		}
+		intf.Namespace = namespace
+		if isDefinition {
+			// Multus plumbs the interface through the definition
+			intf.Name = network
+			intf.NetAttachDef = namespace + "/" + element.Name
+		}
+		if used[intf.Interface] {
+			r.recorder.Eventf(pod, corev1.EventTypeWarning, "InvalidNetworkAnnotation",
+				"Interface %s of network %s is already used, the network is skipped", intf.Interface, element.Name)
+			continue
+		}
+		used[intf.Interface] = true
+		interfaces = append(interfaces, intf)
+	}
+	return interfaces, nil
+}
+
+// ovnNetworkExists returns whether the name selects a ProviderNetwork of the
+// namespace or of the default namespace, or a Network
+func (r *ReconcilePod) ovnNetworkExists(namespace, name string) (bool, error) {
+	for _, ns := range []string{namespace, "default"} {
+		err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: name}, &k8sv1alpha1.ProviderNetwork{})
+		if err == nil {
+			return true, nil
+		}
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name}, &k8sv1alpha1.Network{})
+	if err == nil {
+		return true, nil
+	}
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// networkAnnotationsChanged tells whether the annotations selecting the
+// networks of the pod changed
+func networkAnnotationsChanged(oldAnnotations, newAnnotations map[string]string) bool {
+	for _, key := range []string{nfnNetworkAnnotation, podnetwork.NetworksAnnotation} {
+		if oldAnnotations[key] != newAnnotations[key] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ReconcilePod) readPodAnnotation(pod *corev1.Pod) (*podnetwork.NetworkRequest, error) {
 	annotaion, ok := pod.Annotations[nfnNetworkAnnotation]
 	if !ok {
 		return nil, fmt.Errorf("Invalid annotations")
 	}
-	var nfn nfnNetwork
-	err := json.Unmarshal([]byte(annotaion), &nfn)
+	nfn, err := podnetwork.ParseNetworkRequest(annotaion)
 	if err != nil {
 		log.Error(err, "Invalid nfn annotaion", "annotaiton", annotaion)
 		return nil, err
 	}
-	return &nfn, nil
+	return nfn, nil
 }
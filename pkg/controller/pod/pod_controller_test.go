@@ -0,0 +1,95 @@
+package pod
+
+import (
+	"testing"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
+	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPodController(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pod Controller Test Suite")
+}
+
+var _ = Describe("Network annotations", func() {
+	var annotations map[string]string
+
+	BeforeEach(func() {
+		annotations = map[string]string{
+			nfnNetworkAnnotation:          `{"type": "ovn4nfv", "interface": [{"name": "ovn-priv-net", "interface": "net1"}]}`,
+			podnetwork.NetworksAnnotation: "ovn-port-net@net2",
+			"app":                         "test",
+		}
+	})
+
+	copyWith := func(key, value string) map[string]string {
+		c := make(map[string]string)
+		for k, v := range annotations {
+			c[k] = v
+		}
+		if value == "" {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	It("ignores the other annotations", func() {
+		Expect(networkAnnotationsChanged(annotations, copyWith("app", "other"))).To(BeFalse())
+		Expect(networkAnnotationsChanged(annotations, copyWith(podnetwork.NetworkStatusAnnotation, "[]"))).To(BeFalse())
+	})
+
+	It("tells the changes of the network annotations", func() {
+		Expect(networkAnnotationsChanged(annotations, copyWith(nfnNetworkAnnotation,
+			`{"type": "ovn4nfv", "interface": [{"name": "ovn-priv-net", "interface": "net3"}]}`))).To(BeTrue())
+		Expect(networkAnnotationsChanged(annotations, copyWith(podnetwork.NetworksAnnotation, "ovn-port-net@net4"))).To(BeTrue())
+		Expect(networkAnnotationsChanged(annotations, copyWith(podnetwork.NetworksAnnotation, ""))).To(BeTrue())
+		Expect(networkAnnotationsChanged(nil, annotations)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Selected interfaces", func() {
+	definition := func(name, config string) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "k8s.cni.cncf.io/v1",
+			"kind":       "NetworkAttachmentDefinition",
+			"metadata":   map[string]interface{}{"namespace": "default", "name": name},
+			"spec":       map[string]interface{}{"config": config},
+		}}
+	}
+
+	It("selects the networks of the definitions of ovn4nfv", func() {
+		scheme := runtime.NewScheme()
+		Expect(k8sv1alpha1.AddToScheme(scheme)).To(Succeed())
+		r := &ReconcilePod{
+			client: fake.NewFakeClientWithScheme(scheme,
+				&k8sv1alpha1.Network{ObjectMeta: metav1.ObjectMeta{Name: "ovn-port-net"}},
+				definition("ovn-priv", `{"cniVersion": "0.3.1", "name": "ovn-priv", "type": "ovn4nfvk8s-cni", "network": "ovn-priv-net"}`),
+				definition("macvlan", `{"cniVersion": "0.3.1", "name": "macvlan", "type": "macvlan", "master": "eth0"}`)),
+			recorder: record.NewFakeRecorder(10),
+		}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "pod",
+			Annotations: map[string]string{podnetwork.NetworksAnnotation: "ovn-priv,macvlan,ovn-port-net,unknown"},
+		}}
+
+		interfaces, err := r.selectedInterfaces(pod, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(interfaces).To(Equal([]podnetwork.InterfaceRequest{
+			{Name: "ovn-priv-net", Namespace: "default", Interface: "net1", NetAttachDef: "default/ovn-priv"},
+			{Name: "ovn-port-net", Namespace: "default", Interface: "net3"},
+		}))
+	})
+})
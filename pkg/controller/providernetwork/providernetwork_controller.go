@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"ovn4nfv-k8s-plugin/internal/pkg/netattachdef"
 	notif "ovn4nfv-k8s-plugin/internal/pkg/nfnNotify"
 	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
 	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
@@ -18,6 +21,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+	"strings"
+	"time"
 )
 
 var log = logf.Log.WithName("controller_providernetwork")
@@ -30,7 +35,7 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileProviderNetwork{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileProviderNetwork{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor("providernetwork-controller")}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -56,8 +61,9 @@ var _ reconcile.Reconciler = &ReconcileProviderNetwork{}
 type ReconcileProviderNetwork struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
 }
 type reconcileFun func(instance *k8sv1alpha1.ProviderNetwork, reqLogger logr.Logger) error
 
@@ -90,13 +96,42 @@ func (r *ReconcileProviderNetwork) Reconcile(request reconcile.Request) (reconci
 			return reconcile.Result{}, err
 		}
 	}
+	// Check again until all the nodes applied the provider network
+	if k8sv1alpha1.IsConditionTrue(instance.Status.Conditions, k8sv1alpha1.ConditionProgressing) {
+		return reconcile.Result{RequeueAfter: progressInterval}, nil
+	}
+	// The disconnected nodes may take long to come back, check them less
+	// and less often
+	if degraded := k8sv1alpha1.FindCondition(instance.Status.Conditions, k8sv1alpha1.ConditionDegraded); degraded != nil &&
+		degraded.Status == k8sv1alpha1.ConditionTrue {
+		return reconcile.Result{RequeueAfter: degradedInterval(time.Since(degraded.LastTransitionTime.Time))}, nil
+	}
 	return reconcile.Result{}, nil
 }
 
 const (
 	nfnProviderNetworkFinalizer = "nfnCleanUpProviderNetwork"
+	// progressInterval is how often the nodes applying the provider
+	// network are checked
+	progressInterval = 5 * time.Second
+	// maxDegradedInterval is the longest interval between the checks of
+	// the disconnected nodes
+	maxDegradedInterval = 5 * time.Minute
 )
 
+// degradedInterval returns the interval before checking the disconnected
+// nodes again, it doubles the time the provider network has been degraded
+func degradedInterval(degraded time.Duration) time.Duration {
+	interval := 2 * degraded
+	if interval < progressInterval {
+		return progressInterval
+	}
+	if interval > maxDegradedInterval {
+		return maxDegradedInterval
+	}
+	return interval
+}
+
 func (r *ReconcileProviderNetwork) createNetwork(cr *k8sv1alpha1.ProviderNetwork, reqLogger logr.Logger) error {
 
 	if !cr.DeletionTimestamp.IsZero() {
@@ -113,25 +148,51 @@ func (r *ReconcileProviderNetwork) createNetwork(cr *k8sv1alpha1.ProviderNetwork
 		if err != nil && !reflect.DeepEqual(err, fmt.Errorf("LS exists")) {
 			// Log the error
 			reqLogger.Error(err, "Error Creating Network")
+			r.recorder.Eventf(cr, corev1.EventTypeWarning, "CreateNetworkFailed", "Failed to create provider network: %v", err)
 			cr.Status.State = k8sv1alpha1.CreateInternalError
+			setFailed(cr, "CreateFailed", err.Error())
 		} else {
-			err := notif.SendNotif(cr, "create", "")
+			// Notify the nodes once per generation, the nodes that
+			// subscribe later get the provider network in the sync. A
+			// new leader doesn't send again what the previous one sent.
+			var err error
+			if cr.Status.ObservedGeneration != cr.Generation || cr.Status.State != k8sv1alpha1.Created {
+				err = notif.SendNotif(cr, "create", "")
+			}
 			if err != nil {
 				cr.Status.State = k8sv1alpha1.CreateInternalError
 				reqLogger.Error(err, "Error Sending Message")
+				r.recorder.Eventf(cr, corev1.EventTypeWarning, "NotifyAgentsFailed", "Failed to notify the nfn-agents: %v", err)
+				setFailed(cr, "NotifyFailed", err.Error())
 			} else {
 				cr.Status.State = k8sv1alpha1.Created
-			}
-			err = r.client.Status().Update(context.TODO(), cr)
-			if err != nil {
-				return err
+				// Keep the conditions set by the previous leader till
+				// the nodes subscribe again
+				if notif.ProviderNetworkSent(cr) {
+					setProgress(cr)
+				}
 			}
 		}
-		// If OVN internal error don't requeue
+		cr.Status.ObservedGeneration = cr.Generation
+		err = r.client.Status().Update(context.TODO(), cr)
+		if err != nil {
+			return err
+		}
+		if cr.Status.State != k8sv1alpha1.Created {
+			// If OVN internal error don't requeue
+			return nil
+		}
+		err = netattachdef.Sync(context.TODO(), r.client, r.scheme, cr, cr.Spec.CniType, []string{cr.Namespace})
+		if err != nil {
+			reqLogger.Error(err, "Error Syncing NetworkAttachmentDefinition")
+			r.recorder.Eventf(cr, corev1.EventTypeWarning, "SyncNetAttachDefFailed", "Failed to sync NetworkAttachmentDefinition: %v", err)
+			return err
+		}
 		return nil
 		// Add other CNI types here
 	}
 	reqLogger.Info("CNI type not supported", "name", cr.Spec.CniType)
+	r.recorder.Eventf(cr, corev1.EventTypeWarning, "UnsupportedCNIType", "CNI type %s not supported", cr.Spec.CniType)
 	return fmt.Errorf("CNI type not supported")
 }
 
@@ -150,7 +211,9 @@ func (r *ReconcileProviderNetwork) deleteNetwork(cr *k8sv1alpha1.ProviderNetwork
 		if err != nil {
 			// Log the error
 			reqLogger.Error(err, "Error Delete Network")
+			r.recorder.Eventf(cr, corev1.EventTypeWarning, "DeleteNetworkFailed", "Failed to delete provider network: %v", err)
 			cr.Status.State = k8sv1alpha1.DeleteInternalError
+			setFailed(cr, "DeleteFailed", err.Error())
 			err = r.client.Status().Update(context.TODO(), cr)
 			if err != nil {
 				return err
@@ -164,12 +227,62 @@ func (r *ReconcileProviderNetwork) deleteNetwork(cr *k8sv1alpha1.ProviderNetwork
 	return fmt.Errorf("CNI type not supported")
 }
 
+// setConditions sets the Ready, Progressing and Degraded conditions of the
+// provider network
+func setConditions(cr *k8sv1alpha1.ProviderNetwork, ready, progressing, degraded k8sv1alpha1.Condition) {
+	for _, c := range []k8sv1alpha1.Condition{ready, progressing, degraded} {
+		c.ObservedGeneration = cr.Generation
+		k8sv1alpha1.SetCondition(&cr.Status.Conditions, c)
+	}
+}
+
+func setFailed(cr *k8sv1alpha1.ProviderNetwork, reason, message string) {
+	setConditions(cr,
+		k8sv1alpha1.Condition{Type: k8sv1alpha1.ConditionReady, Status: k8sv1alpha1.ConditionFalse, Reason: reason, Message: message},
+		k8sv1alpha1.Condition{Type: k8sv1alpha1.ConditionProgressing, Status: k8sv1alpha1.ConditionFalse, Reason: reason},
+		k8sv1alpha1.Condition{Type: k8sv1alpha1.ConditionDegraded, Status: k8sv1alpha1.ConditionFalse, Reason: reason})
+}
+
+// setProgress sets the conditions from the nodes that applied the provider
+// network
+func setProgress(cr *k8sv1alpha1.ProviderNetwork) {
+	applied, total, disconnected := notif.ProviderNetworkProgress(cr)
+	message := fmt.Sprintf("Applied on %d/%d nodes", applied, total)
+	if total == 0 {
+		message = "No node selected"
+	}
+	ready := k8sv1alpha1.Condition{Type: k8sv1alpha1.ConditionReady, Status: k8sv1alpha1.ConditionTrue, Reason: "Applied", Message: message}
+	progressing := k8sv1alpha1.Condition{Type: k8sv1alpha1.ConditionProgressing, Status: k8sv1alpha1.ConditionFalse, Reason: "Applied", Message: message}
+	degraded := k8sv1alpha1.Condition{Type: k8sv1alpha1.ConditionDegraded, Status: k8sv1alpha1.ConditionFalse, Reason: "Applied"}
+	if applied < total {
+		ready.Status = k8sv1alpha1.ConditionFalse
+		ready.Reason = "Applying"
+	}
+	if applied+len(disconnected) < total {
+		progressing.Status = k8sv1alpha1.ConditionTrue
+		progressing.Reason = "Applying"
+	}
+	if len(disconnected) > 0 {
+		degraded.Status = k8sv1alpha1.ConditionTrue
+		degraded.Reason = "NodesDisconnected"
+		degraded.Message = fmt.Sprintf("Nodes disconnected before applying: %s", strings.Join(disconnected, ", "))
+		ready.Reason = "NodesDisconnected"
+	}
+	setConditions(cr, ready, progressing, degraded)
+}
+
 func (r *ReconcileProviderNetwork) reconcileFinalizers(instance *k8sv1alpha1.ProviderNetwork, reqLogger logr.Logger) (err error) {
 
 	if !instance.DeletionTimestamp.IsZero() {
 		// Instance marked for deletion
 		if utils.Contains(instance.ObjectMeta.Finalizers, nfnProviderNetworkFinalizer) {
 			reqLogger.V(1).Info("Finalizer found - delete network")
+			// Keep the finalizer till the definition is gone, pods must
+			// not select the network once deleted
+			if err = netattachdef.Delete(context.TODO(), r.client, r.scheme, instance, []string{instance.Namespace}); err != nil {
+				reqLogger.Error(err, "Error Deleting NetworkAttachmentDefinition")
+				return err
+			}
 			if err = r.deleteNetwork(instance, reqLogger); err != nil {
 				reqLogger.Error(err, "Delete network")
 			}
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ServiceName is the service in front of the nfn-operator webhooks
+	ServiceName = "nfn-operator"
+	// ConfigName is the ValidatingWebhookConfiguration and the
+	// MutatingWebhookConfiguration of the nfn-operator
+	ConfigName = "nfn-operator-webhook"
+	// certSecretName is the secret shared by the nfn-operator replicas
+	certSecretName = "nfn-operator-webhook-cert"
+	// Certificates are renewed when they expire within certRenewBefore
+	certRenewBefore = 30 * 24 * time.Hour
+)
+
+// conversionCRDs are the CRDs converted by the nfn-operator
+var conversionCRDs = []string{
+	"networkchainings.k8s.plugin.opnfv.org",
+}
+
+// SetupCerts writes the webhook serving certificate to certDir and sets the
+// CA bundle of the webhook configurations. The certificate is self-signed
+// and kept in a secret so that all the replicas serve the same one.
+func SetupCerts(cfg *rest.Config, certDir, namespace string) error {
+	c, err := client.New(cfg, client.Options{})
+	if err != nil {
+		return err
+	}
+	ctx := context.TODO()
+	secret, err := certSecret(ctx, c, namespace)
+	if err != nil {
+		return err
+	}
+	crt := secret.Data[corev1.TLSCertKey]
+	key := secret.Data[corev1.TLSPrivateKeyKey]
+
+	if err := os.MkdirAll(certDir, 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(certDir, corev1.TLSCertKey), crt, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(certDir, corev1.TLSPrivateKeyKey), key, 0600); err != nil {
+		return err
+	}
+
+	// The certificate chain includes the CA
+	return injectCABundle(ctx, c, crt)
+}
+
+// certSecret returns the secret holding the serving certificate, creating
+// or renewing it as needed
+func certSecret(ctx context.Context, c client.Client, namespace string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: certSecretName}, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	exists := err == nil
+	if exists && certValid(secret.Data[corev1.TLSCertKey]) {
+		return secret, nil
+	}
+
+	host := fmt.Sprintf("%s.%s.svc", ServiceName, namespace)
+	crt, key, err := cert.GenerateSelfSignedCertKey(host, nil, []string{host + ".cluster.local"})
+	if err != nil {
+		return nil, err
+	}
+	secret.Type = corev1.SecretTypeTLS
+	secret.Data = map[string][]byte{
+		corev1.TLSCertKey:       crt,
+		corev1.TLSPrivateKeyKey: key,
+	}
+	if exists {
+		log.Info("Renewing webhook certificate")
+		err = c.Update(ctx, secret)
+	} else {
+		secret.ObjectMeta = metav1.ObjectMeta{Namespace: namespace, Name: certSecretName}
+		err = c.Create(ctx, secret)
+	}
+	if errors.IsAlreadyExists(err) || errors.IsConflict(err) {
+		// Another replica won, use its certificate
+		secret = &corev1.Secret{}
+		err = c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: certSecretName}, secret)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func certValid(crt []byte) bool {
+	block, _ := pem.Decode(crt)
+	if block == nil {
+		return false
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return time.Now().Add(certRenewBefore).Before(leaf.NotAfter)
+}
+
+// injectCABundle sets the CA bundle of the conversion and admission
+// webhooks served by the nfn-operator
+func injectCABundle(ctx context.Context, c client.Client, caBundle []byte) error {
+	for _, name := range conversionCRDs {
+		// Replicas starting together update the same objects
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			crd := &unstructured.Unstructured{}
+			crd.SetAPIVersion("apiextensions.k8s.io/v1beta1")
+			crd.SetKind("CustomResourceDefinition")
+			if err := c.Get(ctx, types.NamespacedName{Name: name}, crd); err != nil {
+				return err
+			}
+			err := unstructured.SetNestedField(crd.Object, base64.StdEncoding.EncodeToString(caBundle),
+				"spec", "conversion", "webhookClientConfig", "caBundle")
+			if err != nil {
+				return err
+			}
+			return c.Update(ctx, crd)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		config := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+		err := c.Get(ctx, types.NamespacedName{Name: ConfigName}, config)
+		if errors.IsNotFound(err) {
+			log.Info("Webhook configuration not found, validating webhooks disabled", "name", ConfigName)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for i := range config.Webhooks {
+			config.Webhooks[i].ClientConfig.CABundle = caBundle
+		}
+		return c.Update(ctx, config)
+	})
+	if err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		config := &admissionregistrationv1beta1.MutatingWebhookConfiguration{}
+		err := c.Get(ctx, types.NamespacedName{Name: ConfigName}, config)
+		if errors.IsNotFound(err) {
+			log.Info("Webhook configuration not found, mutating webhooks disabled", "name", ConfigName)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for i := range config.Webhooks {
+			config.Webhooks[i].ClientConfig.CABundle = caBundle
+		}
+		return c.Update(ctx, config)
+	})
+}
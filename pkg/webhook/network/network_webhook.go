@@ -0,0 +1,210 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+
+	"k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var log = logf.Log.WithName("webhook_network")
+
+// providerNetworkSwitch returns the logical switch of a provider network
+var providerNetworkSwitch = ovn.ProviderNetworkSwitch
+
+// Add registers the Network and ProviderNetwork validating webhooks with the
+// Manager
+func Add(mgr manager.Manager) error {
+	mgr.GetWebhookServer().Register("/validate-network", &webhook.Admission{Handler: &networkValidator{}})
+	mgr.GetWebhookServer().Register("/validate-providernetwork", &webhook.Admission{Handler: &providerNetworkValidator{}})
+	return nil
+}
+
+// validator holds what the Network and ProviderNetwork validators share
+type validator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// InjectClient injects the client into the validator
+func (v *validator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectDecoder injects the decoder into the validator
+func (v *validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// networkValidator rejects Networks that can not be created in OVN
+type networkValidator struct {
+	validator
+}
+
+// Handle validates the Network
+func (v *networkValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cr := &k8sv1alpha1.Network{}
+	if err := v.decoder.Decode(req, cr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if req.Operation == v1beta1.Update {
+		if !cr.DeletionTimestamp.IsZero() {
+			// Allow the finalizer to be removed
+			return admission.Allowed("")
+		}
+		old := &k8sv1alpha1.Network{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if err := validateNetworkUpdate(&old.Spec, &cr.Spec); err != nil {
+			return admission.Denied(err.Error())
+		}
+		return admission.Allowed("")
+	}
+	if err := validateNetworkSpec(&cr.Spec); err != nil {
+		return admission.Denied(err.Error())
+	}
+	return v.checkConflicts(ctx, cr.Name, "Network "+cr.Name, cr.Spec.Ipv4Subnets, cr.Spec.Ipv6Subnets)
+}
+
+// providerNetworkValidator rejects ProviderNetworks that can not be created
+// in OVN or on the nodes
+type providerNetworkValidator struct {
+	validator
+}
+
+// Handle validates the ProviderNetwork
+func (v *providerNetworkValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cr := &k8sv1alpha1.ProviderNetwork{}
+	if err := v.decoder.Decode(req, cr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if req.Operation == v1beta1.Update {
+		if !cr.DeletionTimestamp.IsZero() {
+			// Allow the finalizer to be removed
+			return admission.Allowed("")
+		}
+		old := &k8sv1alpha1.ProviderNetwork{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if err := validateProviderNetworkUpdate(&old.Spec, &cr.Spec); err != nil {
+			return admission.Denied(err.Error())
+		}
+		return admission.Allowed("")
+	}
+	if err := validateProviderNetworkSpec(&cr.Spec); err != nil {
+		return admission.Denied(err.Error())
+	}
+	lsName, err := providerNetworkSwitch(cr.Namespace, cr.Name)
+	if err != nil {
+		log.Error(err, "Failed to get logical switch", "namespace", cr.Namespace, "name", cr.Name)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return v.checkConflicts(ctx, lsName, "ProviderNetwork "+cr.Namespace+"/"+cr.Name, cr.Spec.Ipv4Subnets, cr.Spec.Ipv6Subnets)
+}
+
+// checkConflicts rejects a logical switch name used by another network and
+// subnets overlapping the subnets of the cluster or of the other networks
+func (v *validator) checkConflicts(ctx context.Context, lsName, self string, ipv4Subnets, ipv6Subnets []k8sv1alpha1.IpSubnet) admission.Response {
+	if lsName == ovn.Ovn4nfvDefaultNw || lsName == ovn.JoinSwitch {
+		return admission.Denied(fmt.Sprintf("Name %s is reserved", lsName))
+	}
+	reserved, err := v.reservedSubnets(ctx, lsName, self)
+	if err != nil {
+		if _, ok := err.(nameConflict); ok {
+			return admission.Denied(err.Error())
+		}
+		log.Error(err, "Failed to list networks")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	subnets := append(append([]k8sv1alpha1.IpSubnet{}, ipv4Subnets...), ipv6Subnets...)
+	if subnet, r := overlap(subnets, reserved); r != nil {
+		return admission.Denied(fmt.Sprintf("Subnet %s overlaps %s of %s", subnet, r.subnet, r.name))
+	}
+	return admission.Allowed("")
+}
+
+// nameConflict is returned when another network has the logical switch name
+type nameConflict string
+
+func (e nameConflict) Error() string {
+	return string(e)
+}
+
+// reservedSubnets returns the subnets of the join switch, of the default
+// network and of the networks other than self
+func (v *validator) reservedSubnets(ctx context.Context, lsName, self string) ([]reservedSubnet, error) {
+	var reserved []reservedSubnet
+	_, join, _ := net.ParseCIDR(ovn.JoinSubnet)
+	reserved = append(reserved, reservedSubnet{name: "the join switch", subnet: join})
+	if _, cidr, err := net.ParseCIDR(os.Getenv("OVN_SUBNET")); err == nil {
+		reserved = append(reserved, reservedSubnet{name: "the default network", subnet: cidr})
+	}
+
+	networks := &k8sv1alpha1.NetworkList{}
+	if err := v.client.List(ctx, networks); err != nil {
+		return nil, err
+	}
+	for _, n := range networks.Items {
+		name := "Network " + n.Name
+		if name == self {
+			continue
+		}
+		if n.Name == lsName {
+			return nil, nameConflict(fmt.Sprintf("%s already uses the logical switch %s", name, lsName))
+		}
+		reserved = append(reserved, networkSubnets(name, n.Spec.Ipv4Subnets, n.Spec.Ipv6Subnets)...)
+	}
+	providerNetworks := &k8sv1alpha1.ProviderNetworkList{}
+	if err := v.client.List(ctx, providerNetworks); err != nil {
+		return nil, err
+	}
+	for _, pn := range providerNetworks.Items {
+		name := "ProviderNetwork " + pn.Namespace + "/" + pn.Name
+		if name == self {
+			continue
+		}
+		pnSwitch := ovn.ProviderNetworkName(pn.Namespace, pn.Name)
+		if pnSwitch != lsName && pn.Name == lsName {
+			// The switch may be named after the provider network only
+			var err error
+			if pnSwitch, err = providerNetworkSwitch(pn.Namespace, pn.Name); err != nil {
+				return nil, err
+			}
+		}
+		if pnSwitch == lsName {
+			return nil, nameConflict(fmt.Sprintf("%s already uses the logical switch %s", name, lsName))
+		}
+		reserved = append(reserved, networkSubnets(name, pn.Spec.Ipv4Subnets, pn.Spec.Ipv6Subnets)...)
+	}
+	return reserved, nil
+}
@@ -0,0 +1,273 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+
+	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// maxIntfNameLen is the maximum length of a Linux interface name
+const maxIntfNameLen = 15
+
+// validateNetworkSpec checks the fields used to create the logical switch
+// of a Network
+func validateNetworkSpec(spec *k8sv1alpha1.NetworkSpec) error {
+	if spec.CniType != "ovn4nfv" {
+		return fmt.Errorf("cniType %q not supported, must be ovn4nfv", spec.CniType)
+	}
+	return validateCommon(spec.Ipv4Subnets, spec.Ipv6Subnets, spec.Routes, spec.DNS)
+}
+
+// validateProviderNetworkSpec checks the fields used to create the logical
+// switch of a ProviderNetwork and to configure it on the nodes
+func validateProviderNetworkSpec(spec *k8sv1alpha1.ProviderNetworkSpec) error {
+	if spec.CniType != "ovn4nfv" {
+		return fmt.Errorf("cniType %q not supported, must be ovn4nfv", spec.CniType)
+	}
+	if err := validateCommon(spec.Ipv4Subnets, spec.Ipv6Subnets, spec.Routes, spec.DNS); err != nil {
+		return err
+	}
+	switch spec.ProviderNetType {
+	case "VLAN":
+		vlan := spec.Vlan
+		id, err := strconv.Atoi(vlan.VlanId)
+		if err != nil || id < 1 || id > 4094 {
+			return fmt.Errorf("vlan.vlanId %q must be a number between 1 and 4094", vlan.VlanId)
+		}
+		if err := validateNodeSelector("vlan.vlanNodeSelector", vlan.VlanNodeSelector, vlan.NodeLabelList); err != nil {
+			return err
+		}
+		if err := validateIntfName("vlan.providerInterfaceName", vlan.ProviderInterfaceName); err != nil {
+			return err
+		}
+		if vlan.LogicalInterfaceName != "" {
+			return validateIntfName("vlan.logicalInterfaceName", vlan.LogicalInterfaceName)
+		}
+	case "DIRECT":
+		direct := spec.Direct
+		if err := validateNodeSelector("direct.directNodeSelector", direct.DirectNodeSelector, direct.NodeLabelList); err != nil {
+			return err
+		}
+		return validateIntfName("direct.providerInterfaceName", direct.ProviderInterfaceName)
+	default:
+		return fmt.Errorf("providerNetType %q not supported, must be VLAN or DIRECT", spec.ProviderNetType)
+	}
+	return nil
+}
+
+func validateCommon(ipv4Subnets, ipv6Subnets []k8sv1alpha1.IpSubnet, routes []k8sv1alpha1.Route, dns k8sv1alpha1.DnsSpec) error {
+	if len(ipv4Subnets) == 0 {
+		return fmt.Errorf("ipv4Subnets must have at least one subnet")
+	}
+	if err := validateSubnets("ipv4Subnets", ipv4Subnets, false); err != nil {
+		return err
+	}
+	if err := validateSubnets("ipv6Subnets", ipv6Subnets, true); err != nil {
+		return err
+	}
+	if err := validateRoutes(routes); err != nil {
+		return err
+	}
+	for _, ns := range dns.Nameservers {
+		if net.ParseIP(ns) == nil {
+			return fmt.Errorf("dns.nameservers: invalid IP address %q", ns)
+		}
+	}
+	return nil
+}
+
+// validateSubnets checks the subnet CIDRs, and that the gateway and the
+// excluded addresses are in the subnet
+func validateSubnets(field string, subnets []k8sv1alpha1.IpSubnet, ipv6 bool) error {
+	for i, s := range subnets {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		_, cidr, err := net.ParseCIDR(s.Subnet)
+		if err != nil {
+			return fmt.Errorf("%s.subnet: invalid CIDR %q", path, s.Subnet)
+		}
+		if ipv6 && cidr.IP.To4() != nil {
+			return fmt.Errorf("%s.subnet: %s is not an IPv6 subnet", path, s.Subnet)
+		}
+		if !ipv6 && cidr.IP.To4() == nil {
+			return fmt.Errorf("%s.subnet: %s is not an IPv4 subnet", path, s.Subnet)
+		}
+		if s.Gateway != "" {
+			gw, _, err := net.ParseCIDR(s.Gateway)
+			if err != nil {
+				gw = net.ParseIP(s.Gateway)
+			}
+			if gw == nil {
+				return fmt.Errorf("%s.gateway: invalid IP address %q", path, s.Gateway)
+			}
+			if !cidr.Contains(gw) {
+				return fmt.Errorf("%s.gateway: %s is outside the subnet %s", path, s.Gateway, s.Subnet)
+			}
+		}
+		if err := validateExcludeIps(path, s.ExcludeIps, cidr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateExcludeIps checks the space separated addresses and ranges such
+// as "10.0.0.2 10.0.0.10..10.0.0.20" excluded from the subnet
+func validateExcludeIps(path, excludeIps string, cidr *net.IPNet) error {
+	for _, item := range strings.Fields(excludeIps) {
+		ips := strings.Split(item, "..")
+		if len(ips) > 2 {
+			return fmt.Errorf("%s.excludeIps: invalid range %q", path, item)
+		}
+		var parsed []net.IP
+		for _, s := range ips {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return fmt.Errorf("%s.excludeIps: invalid IP address %q", path, s)
+			}
+			if !cidr.Contains(ip) {
+				return fmt.Errorf("%s.excludeIps: %s is outside the subnet %s", path, s, cidr)
+			}
+			parsed = append(parsed, ip.To16())
+		}
+		if len(parsed) == 2 && bytes.Compare(parsed[0], parsed[1]) > 0 {
+			return fmt.Errorf("%s.excludeIps: range %q starts after it ends", path, item)
+		}
+	}
+	return nil
+}
+
+func validateRoutes(routes []k8sv1alpha1.Route) error {
+	for i, r := range routes {
+		if _, _, err := net.ParseCIDR(r.Dst); err != nil {
+			return fmt.Errorf("routes[%d].dst: invalid CIDR %q", i, r.Dst)
+		}
+		if r.GW != "" && net.ParseIP(r.GW) == nil {
+			return fmt.Errorf("routes[%d].gw: invalid IP address %q", i, r.GW)
+		}
+	}
+	return nil
+}
+
+// validateNodeSelector checks the all, any or specific node selector and
+// the labels of the specific nodes
+func validateNodeSelector(field, selector string, labelList []string) error {
+	switch {
+	case strings.EqualFold(selector, "all"), strings.EqualFold(selector, "any"):
+		return nil
+	case strings.EqualFold(selector, "specific"):
+		if len(labelList) == 0 {
+			return fmt.Errorf("nodeLabelList is required when %s is specific", field)
+		}
+		if _, err := labels.Parse(strings.Join(labelList, ",")); err != nil {
+			return fmt.Errorf("nodeLabelList: %v", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s %q not supported, must be all, any or specific", field, selector)
+}
+
+func validateIntfName(field, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	if len(name) > maxIntfNameLen {
+		return fmt.Errorf("%s %q is longer than %d characters", field, name, maxIntfNameLen)
+	}
+	return nil
+}
+
+// validateNetworkUpdate rejects changes to the fields that can't be applied
+// to an existing logical switch
+func validateNetworkUpdate(old, new *k8sv1alpha1.NetworkSpec) error {
+	switch {
+	case old.CniType != new.CniType:
+		return immutable("cniType")
+	case !reflect.DeepEqual(old.Ipv4Subnets, new.Ipv4Subnets):
+		return immutable("ipv4Subnets")
+	case !reflect.DeepEqual(old.Ipv6Subnets, new.Ipv6Subnets):
+		return immutable("ipv6Subnets")
+	}
+	return validateRoutes(new.Routes)
+}
+
+// validateProviderNetworkUpdate rejects changes to the fields that can't be
+// applied to an existing logical switch or to the nodes
+func validateProviderNetworkUpdate(old, new *k8sv1alpha1.ProviderNetworkSpec) error {
+	switch {
+	case old.CniType != new.CniType:
+		return immutable("cniType")
+	case !reflect.DeepEqual(old.Ipv4Subnets, new.Ipv4Subnets):
+		return immutable("ipv4Subnets")
+	case !reflect.DeepEqual(old.Ipv6Subnets, new.Ipv6Subnets):
+		return immutable("ipv6Subnets")
+	case old.ProviderNetType != new.ProviderNetType:
+		return immutable("providerNetType")
+	case !reflect.DeepEqual(old.Vlan, new.Vlan):
+		return immutable("vlan")
+	case !reflect.DeepEqual(old.Direct, new.Direct):
+		return immutable("direct")
+	}
+	return validateRoutes(new.Routes)
+}
+
+func immutable(field string) error {
+	return fmt.Errorf("%s is immutable, delete and create the network again to change it", field)
+}
+
+// reservedSubnet is a subnet used by the cluster
+type reservedSubnet struct {
+	name   string
+	subnet *net.IPNet
+}
+
+// overlap returns the reserved subnet overlapping one of the subnets
+func overlap(subnets []k8sv1alpha1.IpSubnet, reserved []reservedSubnet) (string, *reservedSubnet) {
+	for _, s := range subnets {
+		_, cidr, err := net.ParseCIDR(s.Subnet)
+		if err != nil {
+			continue
+		}
+		for i := range reserved {
+			r := &reserved[i]
+			if cidr.Contains(r.subnet.IP) || r.subnet.Contains(cidr.IP) {
+				return s.Subnet, r
+			}
+		}
+	}
+	return "", nil
+}
+
+// networkSubnets returns the subnets of the network as reserved subnets
+func networkSubnets(name string, subnets ...[]k8sv1alpha1.IpSubnet) []reservedSubnet {
+	var reserved []reservedSubnet
+	for _, list := range subnets {
+		for _, s := range list {
+			if _, cidr, err := net.ParseCIDR(s.Subnet); err == nil {
+				reserved = append(reserved, reservedSubnet{name: name, subnet: cidr})
+			}
+		}
+	}
+	return reserved
+}
@@ -0,0 +1,102 @@
+package network
+
+import (
+	"net"
+	"testing"
+
+	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestValidate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Network Webhook Test Suite")
+}
+
+var _ = Describe("Network validation", func() {
+	var spec *k8sv1alpha1.ProviderNetworkSpec
+
+	BeforeEach(func() {
+		spec = &k8sv1alpha1.ProviderNetworkSpec{
+			CniType: "ovn4nfv",
+			Ipv4Subnets: []k8sv1alpha1.IpSubnet{{
+				Name:       "subnet1",
+				Subnet:     "172.16.33.0/24",
+				Gateway:    "172.16.33.1/24",
+				ExcludeIps: "172.16.33.2 172.16.33.5..172.16.33.10",
+			}},
+			ProviderNetType: "VLAN",
+			Vlan: k8sv1alpha1.VlanSpec{
+				VlanId:                "100",
+				ProviderInterfaceName: "eth1",
+				LogicalInterfaceName:  "eth1.100",
+				VlanNodeSelector:      "specific",
+				NodeLabelList:         []string{"kubernetes.io/hostname=minion01"},
+			},
+		}
+	})
+
+	It("accepts a valid provider network", func() {
+		Expect(validateProviderNetworkSpec(spec)).To(Succeed())
+	})
+
+	It("rejects invalid subnets", func() {
+		spec.Ipv4Subnets[0].Subnet = "172.16.33.0/33"
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("invalid CIDR")))
+		spec.Ipv4Subnets[0].Subnet = "2001:db8::/64"
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("not an IPv4 subnet")))
+		spec.Ipv4Subnets = nil
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("at least one subnet")))
+	})
+
+	It("rejects a gateway or excluded IPs outside the subnet", func() {
+		spec.Ipv4Subnets[0].Gateway = "172.16.34.1"
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("outside the subnet")))
+		spec.Ipv4Subnets[0].Gateway = ""
+		spec.Ipv4Subnets[0].ExcludeIps = "172.16.33.10..172.16.34.1"
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("outside the subnet")))
+		spec.Ipv4Subnets[0].ExcludeIps = "172.16.33.10..172.16.33.5"
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("starts after it ends")))
+	})
+
+	It("rejects invalid provider settings", func() {
+		spec.Vlan.VlanId = "4095"
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("between 1 and 4094")))
+		spec.Vlan.VlanId = "100"
+		spec.Vlan.ProviderInterfaceName = ""
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("providerInterfaceName is required")))
+		spec.Vlan.ProviderInterfaceName = "eth1"
+		spec.Vlan.NodeLabelList = nil
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("nodeLabelList is required")))
+		spec.ProviderNetType = "VXLAN"
+		Expect(validateProviderNetworkSpec(spec)).To(MatchError(ContainSubstring("must be VLAN or DIRECT")))
+	})
+
+	It("rejects changes to immutable fields", func() {
+		updated := spec.DeepCopy()
+		updated.Routes = []k8sv1alpha1.Route{{Dst: "10.0.0.0/8", GW: "172.16.33.1"}}
+		Expect(validateProviderNetworkUpdate(spec, updated)).To(Succeed())
+		updated.Vlan.VlanId = "200"
+		Expect(validateProviderNetworkUpdate(spec, updated)).To(MatchError(ContainSubstring("vlan is immutable")))
+
+		network := &k8sv1alpha1.NetworkSpec{CniType: "ovn4nfv", Ipv4Subnets: spec.Ipv4Subnets}
+		updatedNetwork := network.DeepCopy()
+		updatedNetwork.Ipv4Subnets[0].Subnet = "172.16.44.0/24"
+		Expect(validateNetworkUpdate(network, updatedNetwork)).To(MatchError(ContainSubstring("ipv4Subnets is immutable")))
+	})
+
+	It("finds overlapping subnets", func() {
+		_, join, _ := net.ParseCIDR("100.64.1.0/24")
+		reserved := []reservedSubnet{{name: "the join switch", subnet: join}}
+		reserved = append(reserved, networkSubnets("Network ovn-priv-net", []k8sv1alpha1.IpSubnet{{Subnet: "172.16.0.0/16"}})...)
+		subnet, r := overlap(spec.Ipv4Subnets, reserved)
+		Expect(subnet).To(Equal("172.16.33.0/24"))
+		Expect(r.name).To(Equal("Network ovn-priv-net"))
+		_, r = overlap([]k8sv1alpha1.IpSubnet{{Subnet: "100.64.0.0/16"}}, reserved)
+		Expect(r.name).To(Equal("the join switch"))
+		_, r = overlap([]k8sv1alpha1.IpSubnet{{Subnet: "10.0.0.0/24"}}, reserved)
+		Expect(r).To(BeNil())
+	})
+})
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package networkchaining
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	chaining "ovn4nfv-k8s-plugin/internal/pkg/utils"
+	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+	k8sv1alpha2 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var log = logf.Log.WithName("webhook_networkchaining")
+
+// Add registers the NetworkChaining validating webhook with the Manager
+func Add(mgr manager.Manager) error {
+	mgr.GetWebhookServer().Register("/validate-networkchaining", &webhook.Admission{Handler: &chainValidator{}})
+	return nil
+}
+
+// chainValidator rejects chains that can not be built
+type chainValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// InjectClient injects the client into the validator
+func (v *chainValidator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectDecoder injects the decoder into the validator
+func (v *chainValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle validates the NetworkChaining, the API server sends it as v1alpha2
+func (v *chainValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	chain := &k8sv1alpha2.NetworkChaining{}
+	if err := v.decoder.Decode(req, chain); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !chain.DeletionTimestamp.IsZero() {
+		// Allow the finalizer to be removed
+		return admission.Allowed("")
+	}
+	switch chain.Spec.ChainType {
+	case k8sv1alpha2.RoutingChain, k8sv1alpha2.RouterPolicyChain:
+	default:
+		return admission.Denied(fmt.Sprintf("Chaining type %s not supported", chain.Spec.ChainType))
+	}
+	spec := chain.Spec.RoutingSpec
+	if len(spec.LeftNetwork) == 0 || len(spec.RightNetwork) == 0 {
+		return admission.Denied("Left and right networks are required")
+	}
+	_, networkList, err := chaining.ParseNetworkChain(spec)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	for _, n := range spec.LeftNetwork {
+		networkList = append(networkList, n.NetworkName)
+	}
+	for _, n := range spec.RightNetwork {
+		networkList = append(networkList, n.NetworkName)
+	}
+	for _, name := range networkList {
+		kind, err := v.networkKind(ctx, chain.Namespace, name)
+		if err != nil {
+			log.Error(err, "Failed to get network", "name", name)
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if kind == "" {
+			return admission.Denied(fmt.Sprintf("Network %s not found", name))
+		}
+		// Provider networks are not connected to the cluster router
+		if chain.Spec.ChainType == k8sv1alpha2.RouterPolicyChain && kind != "Network" {
+			return admission.Denied(fmt.Sprintf("%s chains need networks connected to the cluster router, %s is a %s",
+				chain.Spec.ChainType, name, kind))
+		}
+	}
+	return admission.Allowed("")
+}
+
+// networkKind returns the kind of the Network or ProviderNetwork usable by
+// pods in the namespace, empty when there is none
+func (v *chainValidator) networkKind(ctx context.Context, namespace, name string) (string, error) {
+	// Network names are global in OVN
+	networks := &k8sv1alpha1.NetworkList{}
+	if err := v.client.List(ctx, networks); err != nil {
+		return "", err
+	}
+	for _, n := range networks.Items {
+		if n.Name == name {
+			return "Network", nil
+		}
+	}
+	for _, ns := range []string{namespace, "default"} {
+		pn := &k8sv1alpha1.ProviderNetwork{}
+		err := v.client.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, pn)
+		if err == nil {
+			return "ProviderNetwork", nil
+		}
+		if !errors.IsNotFound(err) {
+			return "", err
+		}
+	}
+	return "", nil
+}
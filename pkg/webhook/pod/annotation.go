@@ -0,0 +1,186 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pod
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
+)
+
+// interfaceFields are the fields of an interface of the annotation, by
+// lower case name. The pod controller decodes them case-insensitively, the
+// webhook rewrites them with the names of podnetwork.InterfaceRequest.
+var interfaceFields = map[string]string{
+	"name":           "name",
+	"namespace":      "namespace",
+	"interface":      "interface",
+	"nettype":        "netType",
+	"defaultgateway": "defaultGateway",
+	"ipaddress":      "ipAddress",
+	"macaddress":     "macAddress",
+	"gwipaddress":    "gwIpAddress",
+}
+
+// parseAnnotation decodes the nfn-network annotation, rejecting the fields
+// the pod controller doesn't know and the values it can't use. The field
+// names and the values are normalized.
+func parseAnnotation(annotation string) (*podnetwork.NetworkRequest, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(annotation), &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	nfn := &podnetwork.NetworkRequest{}
+	for key, value := range raw {
+		switch strings.ToLower(key) {
+		case "version":
+			// Older versions only lack fields
+		case "type":
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("type must be a string")
+			}
+			nfn.Type = strings.ToLower(s)
+		case "interface":
+			list, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("interface must be a list")
+			}
+			for i, item := range list {
+				fields, ok := item.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("interface[%d] must be an object", i)
+				}
+				intf, err := parseInterface(i, fields)
+				if err != nil {
+					return nil, err
+				}
+				nfn.Interface = append(nfn.Interface, *intf)
+			}
+		default:
+			return nil, fmt.Errorf("unknown field %q, valid fields are version, type and interface", key)
+		}
+	}
+	nfn.Version = podnetwork.Version
+	if nfn.Type == "" {
+		nfn.Type = podnetwork.OvnType
+	}
+	if nfn.Type != podnetwork.OvnType {
+		return nil, fmt.Errorf("type %q not supported, must be %s", nfn.Type, podnetwork.OvnType)
+	}
+	return nfn, validateInterfaces(nfn.Interface)
+}
+
+func parseInterface(i int, fields map[string]interface{}) (*podnetwork.InterfaceRequest, error) {
+	values := make(map[string]string)
+	for key, value := range fields {
+		field, ok := interfaceFields[strings.ToLower(key)]
+		if !ok {
+			return nil, fmt.Errorf("interface[%d]: unknown field %q, valid fields are %s", i, key, validFields())
+		}
+		if _, ok := values[field]; ok {
+			return nil, fmt.Errorf("interface[%d]: field %s set more than once", i, field)
+		}
+		switch v := value.(type) {
+		case string:
+			values[field] = v
+		case bool:
+			if field != "defaultGateway" {
+				return nil, fmt.Errorf("interface[%d]: %s must be a string", i, field)
+			}
+			values[field] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("interface[%d]: %s must be a string", i, field)
+		}
+	}
+
+	intf := &podnetwork.InterfaceRequest{
+		Name:        values["name"],
+		Namespace:   values["namespace"],
+		Interface:   values["interface"],
+		NetType:     values["netType"],
+		IPAddress:   values["ipAddress"],
+		GWIPAddress: values["gwIpAddress"],
+	}
+	if intf.Name == "" {
+		return nil, fmt.Errorf("interface[%d]: name is required", i)
+	}
+	if gw, ok := values["defaultGateway"]; ok && gw != "" {
+		b, err := strconv.ParseBool(gw)
+		if err != nil {
+			return nil, fmt.Errorf("interface[%d]: defaultGateway %q must be true or false", i, gw)
+		}
+		intf.DefaultGateway = strconv.FormatBool(b)
+	}
+	if intf.IPAddress != "" && net.ParseIP(intf.IPAddress) == nil {
+		return nil, fmt.Errorf("interface[%d]: ipAddress %q is not an IP address", i, intf.IPAddress)
+	}
+	if intf.GWIPAddress != "" && net.ParseIP(intf.GWIPAddress) == nil {
+		return nil, fmt.Errorf("interface[%d]: gwIpAddress %q is not an IP address", i, intf.GWIPAddress)
+	}
+	if mac := values["macAddress"]; mac != "" {
+		hw, err := net.ParseMAC(mac)
+		if err != nil || len(hw) != 6 {
+			return nil, fmt.Errorf("interface[%d]: macAddress %q is not a MAC address", i, mac)
+		}
+		intf.MacAddress = hw.String()
+	}
+	return intf, nil
+}
+
+// validateInterfaces checks the interfaces of the pod against each other
+func validateInterfaces(interfaces []podnetwork.InterfaceRequest) error {
+	names := make(map[string]bool)
+	defaultGateway := ""
+	for i, intf := range interfaces {
+		if intf.Interface == "" {
+			if intf.Name != ovn.Ovn4nfvDefaultNw {
+				return fmt.Errorf("interface[%d]: interface is required for network %s", i, intf.Name)
+			}
+			continue
+		}
+		if len(intf.Interface) > 15 || strings.ContainsAny(intf.Interface, "/: \t") {
+			return fmt.Errorf("interface[%d]: %q is not a valid interface name", i, intf.Interface)
+		}
+		if names[intf.Interface] {
+			return fmt.Errorf("interface[%d]: interface %s is used more than once", i, intf.Interface)
+		}
+		names[intf.Interface] = true
+		if intf.DefaultGateway == "true" {
+			if defaultGateway != "" {
+				return fmt.Errorf("interface[%d]: %s and %s both set defaultGateway", i, defaultGateway, intf.Interface)
+			}
+			defaultGateway = intf.Interface
+		}
+	}
+	return nil
+}
+
+func validFields() string {
+	var fields []string
+	for _, field := range interfaceFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, ", ")
+}
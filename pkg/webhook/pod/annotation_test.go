@@ -0,0 +1,56 @@
+package pod
+
+import (
+	"encoding/json"
+	"testing"
+
+	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAnnotation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pod Webhook Test Suite")
+}
+
+var _ = Describe("Pod annotation", func() {
+	It("normalizes the field names and values", func() {
+		nfn, err := parseAnnotation(`{"type": "ovn4nfv", "interface": [{"name": "left-pnetwork", "interface": "net0",
+			"defaultgateway": "True", "gwipaddress": "172.30.10.3", "MacAddress": "0A:00:00:00:00:3C"}]}`)
+		Expect(err).NotTo(HaveOccurred())
+		normalized, err := json.Marshal(nfn)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(normalized)).To(Equal(`{"version":"1","type":"ovn4nfv","interface":[{"name":"left-pnetwork","interface":"net0",` +
+			`"defaultGateway":"true","macAddress":"0a:00:00:00:00:3c","gwIpAddress":"172.30.10.3"}]}`))
+	})
+
+	It("rejects unknown fields and invalid values", func() {
+		_, err := parseAnnotation(`{"type": "ovn4nfv", "interface": [{"name": "net", "interface": "net0", "ipadress": "10.0.0.5"}]}`)
+		Expect(err).To(MatchError(ContainSubstring(`unknown field "ipadress"`)))
+		_, err = parseAnnotation(`{"type": "ovn4nfv", "interface": [{"name": "net", "interface": "net0", "ipAddress": "10.0.0.5/24"}]}`)
+		Expect(err).To(MatchError(ContainSubstring("not an IP address")))
+		_, err = parseAnnotation(`{"type": "ovn4nfv", "interface": [{"name": "net", "interface": "net0", "defaultGateway": "yes"}]}`)
+		Expect(err).To(MatchError(ContainSubstring("must be true or false")))
+		_, err = parseAnnotation(`{"type": "multus", "interface": []}`)
+		Expect(err).To(MatchError(ContainSubstring("not supported")))
+	})
+
+	It("rejects conflicting interfaces", func() {
+		_, err := parseAnnotation(`{"type": "ovn4nfv", "interface": [{"name": "net1", "interface": "net0"}, {"name": "net2", "interface": "net0"}]}`)
+		Expect(err).To(MatchError(ContainSubstring("used more than once")))
+		_, err = parseAnnotation(`{"type": "ovn4nfv", "interface": [{"name": "net1", "interface": "net0", "defaultGateway": "true"},
+			{"name": "net2", "interface": "net1", "defaultGateway": true}]}`)
+		Expect(err).To(MatchError(ContainSubstring("both set defaultGateway")))
+		_, err = parseAnnotation(`{"type": "ovn4nfv", "interface": [{"name": "net1"}]}`)
+		Expect(err).To(MatchError(ContainSubstring("interface is required")))
+	})
+
+	It("checks static IP addresses against the subnets", func() {
+		subnets := []k8sv1alpha1.IpSubnet{{Subnet: "172.16.33.0/24", Gateway: "172.16.33.1/24"}}
+		Expect(checkIPAddress("172.16.33.5", subnets)).To(Succeed())
+		Expect(checkIPAddress("172.16.33.1", subnets)).To(MatchError(ContainSubstring("is the gateway")))
+		Expect(checkIPAddress("172.16.34.5", subnets)).To(MatchError(ContainSubstring("outside the subnets")))
+	})
+})
@@ -0,0 +1,248 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"ovn4nfv-k8s-plugin/internal/pkg/netattachdef"
+	"ovn4nfv-k8s-plugin/internal/pkg/ovn"
+	"ovn4nfv-k8s-plugin/internal/pkg/podnetwork"
+	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+
+	"k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var log = logf.Log.WithName("webhook_pod")
+
+// nfnNetworkAnnotation lists the networks of the pod
+const nfnNetworkAnnotation = podnetwork.NetworkAnnotation
+
+// Add registers the pod mutating webhook with the Manager
+func Add(mgr manager.Manager) error {
+	mgr.GetWebhookServer().Register("/mutate-pod", &webhook.Admission{Handler: &podMutator{}})
+	return nil
+}
+
+// podMutator rejects pods whose nfn-network annotation can't be applied and
+// normalizes the annotation of the others
+type podMutator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// InjectClient injects the client into the mutator
+func (m *podMutator) InjectClient(c client.Client) error {
+	m.client = c
+	return nil
+}
+
+// InjectDecoder injects the decoder into the mutator
+func (m *podMutator) InjectDecoder(d *admission.Decoder) error {
+	m.decoder = d
+	return nil
+}
+
+// Handle validates the networks annotation and validates and normalizes the
+// nfn-network annotation of the pod
+func (m *podMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pod := &corev1.Pod{}
+	if err := m.decoder.Decode(req, pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if pod.Spec.HostNetwork {
+		return admission.Allowed("")
+	}
+	old := &corev1.Pod{}
+	if req.Operation == v1beta1.Update {
+		if err := m.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+	if networks, ok := pod.Annotations[podnetwork.NetworksAnnotation]; ok && old.Annotations[podnetwork.NetworksAnnotation] != networks {
+		reason, err := m.checkNetworkSelection(ctx, req.Namespace, networks)
+		if err != nil {
+			log.Error(err, "Failed to check the networks annotation")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if reason != "" {
+			return admission.Denied(fmt.Sprintf("Invalid %s annotation: %s", podnetwork.NetworksAnnotation, reason))
+		}
+	}
+	annotation, ok := pod.Annotations[nfnNetworkAnnotation]
+	if !ok || old.Annotations[nfnNetworkAnnotation] == annotation {
+		return admission.Allowed("")
+	}
+
+	nfn, err := parseAnnotation(annotation)
+	if err != nil {
+		return admission.Denied(fmt.Sprintf("Invalid %s annotation: %v", nfnNetworkAnnotation, err))
+	}
+	for i, intf := range nfn.Interface {
+		namespace := intf.Namespace
+		if namespace == "" {
+			namespace = req.Namespace
+		}
+		subnets, err := m.networkSubnets(ctx, namespace, intf.Name)
+		if err != nil {
+			log.Error(err, "Failed to get network", "name", intf.Name)
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if subnets == nil {
+			return admission.Denied(fmt.Sprintf("Invalid %s annotation: interface[%d]: network %s not found",
+				nfnNetworkAnnotation, i, intf.Name))
+		}
+		if err := checkIPAddress(intf.IPAddress, subnets); err != nil {
+			return admission.Denied(fmt.Sprintf("Invalid %s annotation: interface[%d]: %v", nfnNetworkAnnotation, i, err))
+		}
+	}
+
+	normalized, err := json.Marshal(nfn)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if string(normalized) == annotation {
+		return admission.Allowed("")
+	}
+	// Only the annotation is patched, the fields of the pod this API version
+	// doesn't know are kept
+	patch, err := json.Marshal([]map[string]string{{"op": "replace", "path": annotationPath, "value": string(normalized)}})
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	patchType := v1beta1.PatchTypeJSONPatch
+	return admission.Response{AdmissionResponse: v1beta1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patch,
+		PatchType: &patchType,
+	}}
+}
+
+// annotationPath is the JSON pointer of the nfn-network annotation
+var annotationPath = "/metadata/annotations/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(nfnNetworkAnnotation)
+
+// checkNetworkSelection returns why the networks annotation can't be applied,
+// "" when it can. The static addresses are checked for the elements selecting
+// ovn4nfv networks only, the other elements are left to the CNI they select.
+func (m *podMutator) checkNetworkSelection(ctx context.Context, podNamespace, annotation string) (string, error) {
+	elements, err := podnetwork.ParseNetworkSelection(annotation)
+	if err != nil {
+		return err.Error(), nil
+	}
+	for i, element := range elements {
+		intf, err := element.ToInterfaceRequest(i)
+		if err != nil {
+			return err.Error(), nil
+		}
+		namespace := element.Namespace
+		if namespace == "" {
+			namespace = podNamespace
+		}
+		// Resolved the way the pod controller does
+		network, isDefinition, err := netattachdef.Network(ctx, m.client, namespace, element.Name)
+		if err != nil {
+			return "", err
+		}
+		if !isDefinition {
+			network = element.Name
+		}
+		if network == "" {
+			continue
+		}
+		subnets, err := m.networkSubnets(ctx, namespace, network)
+		if err != nil {
+			return "", err
+		}
+		if subnets == nil {
+			if isDefinition {
+				return fmt.Sprintf("network %s of %s/%s not found", network, namespace, element.Name), nil
+			}
+			continue
+		}
+		if err := checkIPAddress(intf.IPAddress, subnets); err != nil {
+			return fmt.Sprintf("network %s: %v", element.Name, err), nil
+		}
+	}
+	return "", nil
+}
+
+// networkSubnets returns the subnets of the network the pod interface is
+// attached to, nil when there is none. The networks are looked up the way
+// the pod controller picks the logical switch.
+func (m *podMutator) networkSubnets(ctx context.Context, namespace, name string) ([]k8sv1alpha1.IpSubnet, error) {
+	if name == ovn.Ovn4nfvDefaultNw {
+		return []k8sv1alpha1.IpSubnet{{Subnet: os.Getenv("OVN_SUBNET")}}, nil
+	}
+	// Provider network in the pod namespace takes precedence
+	for _, ns := range []string{namespace, "default"} {
+		pn := &k8sv1alpha1.ProviderNetwork{}
+		err := m.client.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, pn)
+		if err == nil {
+			return append(pn.Spec.Ipv4Subnets, pn.Spec.Ipv6Subnets...), nil
+		}
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	network := &k8sv1alpha1.Network{}
+	err := m.client.Get(ctx, types.NamespacedName{Name: name}, network)
+	if err == nil {
+		return append(network.Spec.Ipv4Subnets, network.Spec.Ipv6Subnets...), nil
+	}
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// checkIPAddress checks that the static IP address of the interface is in
+// one of the subnets of the network and isn't its gateway
+func checkIPAddress(ipAddress string, subnets []k8sv1alpha1.IpSubnet) error {
+	if ipAddress == "" {
+		return nil
+	}
+	ip := net.ParseIP(ipAddress)
+	for _, s := range subnets {
+		_, cidr, err := net.ParseCIDR(s.Subnet)
+		if err != nil || !cidr.Contains(ip) {
+			continue
+		}
+		gw, _, err := net.ParseCIDR(s.Gateway)
+		if err != nil {
+			gw = net.ParseIP(s.Gateway)
+		}
+		if gw != nil && gw.Equal(ip) {
+			return fmt.Errorf("ipAddress %s is the gateway of the subnet %s", ipAddress, s.Subnet)
+		}
+		return nil
+	}
+	return fmt.Errorf("ipAddress %s is outside the subnets of the network", ipAddress)
+}
@@ -0,0 +1,83 @@
+package pod
+
+import (
+	"context"
+	"net/http"
+
+	k8sv1alpha1 "ovn4nfv-k8s-plugin/pkg/apis/k8s/v1alpha1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("Pod mutator", func() {
+	var mutator *podMutator
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(k8sv1alpha1.AddToScheme(scheme)).To(Succeed())
+		decoder, err := admission.NewDecoder(scheme)
+		Expect(err).NotTo(HaveOccurred())
+		mutator = &podMutator{
+			client: fake.NewFakeClientWithScheme(scheme, &k8sv1alpha1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "ovn-priv-net"},
+				Spec: k8sv1alpha1.NetworkSpec{
+					Ipv4Subnets: []k8sv1alpha1.IpSubnet{{Subnet: "172.16.44.0/24", Gateway: "172.16.44.1/24"}},
+				},
+			}),
+			decoder: decoder,
+		}
+	})
+
+	request := func(raw string) admission.Request {
+		return admission.Request{AdmissionRequest: v1beta1.AdmissionRequest{
+			Operation: v1beta1.Create,
+			Namespace: "default",
+			Object:    runtime.RawExtension{Raw: []byte(raw)},
+		}}
+	}
+
+	It("patches the annotation only", func() {
+		resp := mutator.Handle(context.TODO(), request(`{"apiVersion": "v1", "kind": "Pod",
+			"metadata": {"name": "pod", "annotations": {"k8s.plugin.opnfv.org/nfn-network":
+				"{\"type\": \"ovn4nfv\", \"interface\": [{\"name\": \"ovn-priv-net\", \"interface\": \"net0\", \"defaultgateway\": \"False\"}]}"}},
+			"spec": {"containers": [{"name": "c", "image": "busybox"}], "fieldOfANewerRelease": true}}`))
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(*resp.PatchType).To(Equal(v1beta1.PatchTypeJSONPatch))
+		Expect(resp.Patch).To(MatchJSON(`[{"op": "replace", "path": "/metadata/annotations/k8s.plugin.opnfv.org~1nfn-network",
+			"value": "{\"version\":\"1\",\"type\":\"ovn4nfv\",\"interface\":[{\"name\":\"ovn-priv-net\",\"interface\":\"net0\",\"defaultGateway\":\"false\"}]}"}]`))
+	})
+
+	It("denies the interfaces of unknown networks", func() {
+		resp := mutator.Handle(context.TODO(), request(`{"apiVersion": "v1", "kind": "Pod",
+			"metadata": {"name": "pod", "annotations": {"k8s.plugin.opnfv.org/nfn-network":
+				"{\"type\": \"ovn4nfv\", \"interface\": [{\"name\": \"ovn-port-net\", \"interface\": \"net0\"}]}"}}}`))
+		Expect(resp.Allowed).To(BeFalse())
+	})
+
+	It("checks the addresses of the networks annotation", func() {
+		resp := mutator.Handle(context.TODO(), request(`{"apiVersion": "v1", "kind": "Pod",
+			"metadata": {"name": "pod", "annotations": {"k8s.v1.cni.cncf.io/networks":
+				"[{\"name\": \"ovn-priv-net\", \"ips\": [\"172.16.44.10\"]}, {\"name\": \"macvlan-conf\"}]"}}}`))
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(resp.Patch).To(BeEmpty())
+
+		for _, networks := range []string{
+			`[{\"name\": \"ovn-priv-net\", \"ips\": [\"172.16.33.10\"]}]`,
+			`[{\"name\": \"ovn-priv-net\", \"ips\": [\"not-an-ip\"]}]`,
+			`[{\"name\": \"ovn-priv-net\"`,
+		} {
+			resp = mutator.Handle(context.TODO(), request(`{"apiVersion": "v1", "kind": "Pod",
+				"metadata": {"name": "pod", "annotations": {"k8s.v1.cni.cncf.io/networks": "`+networks+`"}}}`))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Code).To(Equal(int32(http.StatusForbidden)))
+		}
+	})
+})
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2020 Intel Corporation, Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+var log = logf.Log.WithName("webhook")
+
+// AddToManagerFuncs is a list of functions to add all Webhooks to the Manager
+var AddToManagerFuncs []func(manager.Manager) error
+
+// AddToManager adds the conversion webhook and all the admission Webhooks
+// to the Manager
+func AddToManager(m manager.Manager) error {
+	// Converts the CRDs with multiple versions through the hub version
+	m.GetWebhookServer().Register("/convert", &conversion.Webhook{})
+	for _, f := range AddToManagerFuncs {
+		if err := f(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}